@@ -0,0 +1,73 @@
+package badger
+
+import (
+	"context"
+	"io"
+
+	gobadger "github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/ristretto/v2/z"
+)
+
+// Max pending writes of Load
+const DefaultLoadMaxPendingWrites = 256
+
+// Backup dumps versions newer than since to w, returns the version to pass as since for the next incremental backup
+//
+//	eg: since, err := db.Backup(file, 0)
+//	eg: since, err = db.Backup(incrementalFile, since)
+//	returns ErrManagedTxn in managed mode
+func (b *BadgerDB) Backup(w io.Writer, since uint64) (uint64, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return 0, err
+	}
+	if b.managedMode {
+		return 0, ErrManagedTxn
+	}
+	return db.Backup(w, since)
+}
+
+// Load restores a backup created by Backup, should be called on an idle database
+func (b *BadgerDB) Load(r io.Reader) error {
+	db, err := b.Connect()
+	if err != nil {
+		return err
+	}
+	return db.Load(r, DefaultLoadMaxPendingWrites)
+}
+
+// Stream exports the latest version of keys with prefix using parallel reads
+//
+//	fn is called serially, key and value are only valid during the call
+//	returns ErrManagedTxn in managed mode, use StreamAt
+func (b *BadgerDB) Stream(ctx context.Context, prefix []byte, fn func(key, value []byte) error) error {
+	db, err := b.Connect()
+	if err != nil {
+		return err
+	}
+	if b.managedMode {
+		return ErrManagedTxn
+	}
+	return runStream(ctx, db.NewStream(), prefix, fn)
+}
+
+func runStream(ctx context.Context, stream *gobadger.Stream, prefix []byte, fn func(key, value []byte) error) error {
+	stream.Prefix = prefix
+	stream.LogPrefix = "qdb.Stream"
+	stream.Send = func(buf *z.Buffer) error {
+		list, err := gobadger.BufferToKVList(buf)
+		if err != nil {
+			return err
+		}
+		for _, kv := range list.Kv {
+			if kv.StreamDone {
+				continue
+			}
+			if err := fn(kv.Key, kv.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return stream.Orchestrate(ctx)
+}
@@ -1,7 +1,9 @@
 package badger
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	gobadger "github.com/dgraph-io/badger/v4"
 )
@@ -14,9 +16,24 @@ type BadgerDB struct {
 	FileName       string
 	Options        *Options
 	memoryMode     bool
+	managedMode    bool
 	encryptionKey  []byte
 	indexCacheSize int64
 	logger         gobadger.Logger
+	loggerSet      bool
+	gcInterval     time.Duration
+	gcDiscardRatio float64
+	gcCallback     func(GCResult)
+	gcStop         chan struct{}
+	gcDone         chan struct{}
+	watchMu        sync.Mutex
+	watchWg        sync.WaitGroup
+	watchCancels   map[uint64]context.CancelFunc
+	watchID        uint64
+	watchClosed    bool
+	resMu          sync.Mutex
+	sequences      []*Sequence
+	mergeOperators []*MergeOperator
 	once           sync.Once
 	db             *gobadger.DB
 	err            error
@@ -29,8 +46,10 @@ func New(filename string, options *Options) *BadgerDB {
 	}
 }
 
+// SetLogger sets the logger in all modes, nil disables logging (should be called before Connect)
 func (b *BadgerDB) SetLogger(logger Logger) {
 	b.logger = logger
+	b.loggerSet = true
 }
 
 func (b *BadgerDB) SetMemoryMode(memoryMode bool) {
@@ -56,6 +75,10 @@ func (b *BadgerDB) Connect() (*gobadger.DB, error) {
 			opts = opts.WithInMemory(true)
 			opts.Dir = ""
 			opts.ValueDir = ""
+		}
+
+		// memory mode is silent unless a logger is set
+		if b.loggerSet || b.memoryMode {
 			opts = opts.WithLogger(b.logger)
 		}
 
@@ -66,17 +89,29 @@ func (b *BadgerDB) Connect() (*gobadger.DB, error) {
 			}
 		}
 
-		b.db, b.err = gobadger.Open(opts)
+		if b.managedMode {
+			b.db, b.err = gobadger.OpenManaged(opts)
+		} else {
+			b.db, b.err = gobadger.Open(opts)
+		}
+		if b.err == nil {
+			b.startGC()
+		}
 	})
 
 	return b.db, b.err
 }
 
 func (b *BadgerDB) Close() error {
+	b.stopWatch()
+	b.stopGC()
+	err := b.releaseResources()
 	if b.db != nil {
-		return b.db.Close()
+		if e := b.db.Close(); e != nil {
+			return e
+		}
 	}
-	return nil
+	return err
 }
 
 func (b *BadgerDB) View(fn func(txn *Txn) error) error {
@@ -92,6 +127,9 @@ func (b *BadgerDB) Update(fn func(txn *Txn) error) error {
 	if err != nil {
 		return err
 	}
+	if b.managedMode {
+		return ErrManagedTxn
+	}
 	return db.Update(fn)
 }
 
@@ -100,6 +138,9 @@ func (b *BadgerDB) Begin(writable bool) (*Txn, func() error, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if b.managedMode {
+		return nil, nil, ErrManagedTxn
+	}
 	txn := db.NewTransaction(writable)
 	commit := func() error {
 		if err := txn.Commit(); err != nil {
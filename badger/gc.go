@@ -0,0 +1,107 @@
+package badger
+
+import (
+	"errors"
+	"time"
+
+	gobadger "github.com/dgraph-io/badger/v4"
+)
+
+// Default discard ratio of value log GC, a file is rewritten if at least half of it can be discarded
+const DefaultGCDiscardRatio = 0.5
+
+// GCResult result of a value log GC run
+type GCResult struct {
+	Rewrites int
+	Duration time.Duration
+	Err      error
+}
+
+// SetGC enables the background value log GC (should be called before Connect)
+//
+//	interval 0 disables it, discardRatio 0 means DefaultGCDiscardRatio
+//	eg: db.SetGC(10*time.Minute, 0.5)
+func (b *BadgerDB) SetGC(interval time.Duration, discardRatio float64) {
+	b.gcInterval = interval
+	b.gcDiscardRatio = discardRatio
+}
+
+// SetGCCallback sets fn called after each background GC run
+func (b *BadgerDB) SetGCCallback(fn func(result GCResult)) {
+	b.gcCallback = fn
+}
+
+func (b *BadgerDB) discardRatio() float64 {
+	if b.gcDiscardRatio <= 0 || b.gcDiscardRatio >= 1 {
+		return DefaultGCDiscardRatio
+	}
+	return b.gcDiscardRatio
+}
+
+// RunGC runs value log GC until no file is rewritten, returns the number of rewritten files
+//
+//	GC is skipped in memory mode
+func (b *BadgerDB) RunGC() (int, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return 0, err
+	}
+	return runGC(db, b.discardRatio())
+}
+
+func runGC(db *gobadger.DB, discardRatio float64) (int, error) {
+	if db.Opts().InMemory {
+		return 0, nil
+	}
+
+	rewrites := 0
+	for {
+		err := db.RunValueLogGC(discardRatio)
+		switch {
+		case err == nil:
+			rewrites++
+		case errors.Is(err, gobadger.ErrNoRewrite), errors.Is(err, gobadger.ErrRejected):
+			return rewrites, nil
+		default:
+			return rewrites, err
+		}
+	}
+}
+
+func (b *BadgerDB) startGC() {
+	opts := b.db.Opts()
+	if b.gcInterval <= 0 || opts.InMemory || opts.ReadOnly {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	b.gcStop, b.gcDone = stop, done
+
+	go func(db *gobadger.DB, interval time.Duration, discardRatio float64, callback func(GCResult)) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				start := time.Now()
+				rewrites, err := runGC(db, discardRatio)
+				if callback != nil {
+					callback(GCResult{Rewrites: rewrites, Duration: time.Since(start), Err: err})
+				}
+			}
+		}
+	}(b.db, b.gcInterval, b.discardRatio(), b.gcCallback)
+}
+
+func (b *BadgerDB) stopGC() {
+	if b.gcStop == nil {
+		return
+	}
+	close(b.gcStop)
+	<-b.gcDone
+	b.gcStop, b.gcDone = nil, nil
+}
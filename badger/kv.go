@@ -0,0 +1,170 @@
+package badger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gobadger "github.com/dgraph-io/badger/v4"
+)
+
+var ErrKeyNotFound = gobadger.ErrKeyNotFound
+
+// KeyNotFoundError returned by helpers when key not exists or expired, matches ErrKeyNotFound with errors.Is
+type KeyNotFoundError struct {
+	Key []byte
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return fmt.Sprintf("key not found: %q", e.Key)
+}
+
+func (e *KeyNotFoundError) Unwrap() error {
+	return ErrKeyNotFound
+}
+
+// IsNotFound checks if err is a not-found error
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrKeyNotFound)
+}
+
+// Entry key-value with metadata, ExpiresAt is unix seconds, 0 means never
+//
+//	Deleted is only set by KeyVersions
+type Entry struct {
+	Key       []byte
+	Value     []byte
+	UserMeta  byte
+	ExpiresAt uint64
+	Version   uint64
+	Deleted   bool
+}
+
+func newEntry(item *gobadger.Item) (*Entry, error) {
+	value, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{
+		Key:       item.KeyCopy(nil),
+		Value:     value,
+		UserMeta:  item.UserMeta(),
+		ExpiresAt: item.ExpiresAt(),
+		Version:   item.Version(),
+	}, nil
+}
+
+func getItem(txn *Txn, key []byte) (*gobadger.Item, error) {
+	item, err := txn.Get(key)
+	if errors.Is(err, gobadger.ErrKeyNotFound) {
+		return nil, &KeyNotFoundError{Key: key}
+	}
+	return item, err
+}
+
+// Get returns value of key
+func (b *BadgerDB) Get(key []byte) ([]byte, error) {
+	entry, err := b.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+// GetEntry returns value and metadata of key
+func (b *BadgerDB) GetEntry(key []byte) (*Entry, error) {
+	var entry *Entry
+	err := b.View(func(txn *Txn) error {
+		item, err := getItem(txn, key)
+		if err != nil {
+			return err
+		}
+		entry, err = newEntry(item)
+		return err
+	})
+	return entry, err
+}
+
+// Set create key-value
+func (b *BadgerDB) Set(key, value []byte) error {
+	return b.SetWithMeta(key, value, 0, 0)
+}
+
+// SetWithTTL create key-value which expires after ttl
+func (b *BadgerDB) SetWithTTL(key, value []byte, ttl time.Duration) error {
+	return b.SetWithMeta(key, value, 0, ttl)
+}
+
+// SetWithMeta create key-value with user metadata, ttl 0 means never expires
+func (b *BadgerDB) SetWithMeta(key, value []byte, meta byte, ttl time.Duration) error {
+	return b.Update(func(txn *Txn) error {
+		e := gobadger.NewEntry(key, value).WithMeta(meta)
+		if ttl > 0 {
+			e = e.WithTTL(ttl)
+		}
+		return txn.SetEntry(e)
+	})
+}
+
+// Delete delete a key
+func (b *BadgerDB) Delete(key []byte) error {
+	return b.Update(func(txn *Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// Has check a key
+func (b *BadgerDB) Has(key []byte) (bool, error) {
+	err := b.View(func(txn *Txn) error {
+		_, err := getItem(txn, key)
+		return err
+	})
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetMany returns values of keys in one transaction, missing keys are omitted
+func (b *BadgerDB) GetMany(keys [][]byte) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	err := b.View(func(txn *Txn) error {
+		for _, key := range keys {
+			item, err := getItem(txn, key)
+			if IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			result[string(key)] = value
+		}
+		return nil
+	})
+	return result, err
+}
+
+// PrefixScan iterates keys with prefix in key order
+func (b *BadgerDB) PrefixScan(prefix []byte, fn func(entry *Entry) error) error {
+	return b.View(func(txn *Txn) error {
+		opts := gobadger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			entry, err := newEntry(it.Item())
+			if err != nil {
+				return err
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package badger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger adapts a slog.Logger to badger, nil means slog.Default()
+//
+//	eg: db.SetLogger(badger.NewSlogLogger(slog.Default().With("component", "badger")))
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) log(level slog.Level, format string, args ...any) {
+	if !l.logger.Enabled(context.Background(), level) {
+		return
+	}
+	l.logger.Log(context.Background(), level, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+func (l *slogLogger) Errorf(format string, args ...any) {
+	l.log(slog.LevelError, format, args...)
+}
+
+func (l *slogLogger) Warningf(format string, args ...any) {
+	l.log(slog.LevelWarn, format, args...)
+}
+
+func (l *slogLogger) Infof(format string, args ...any) {
+	l.log(slog.LevelInfo, format, args...)
+}
+
+func (l *slogLogger) Debugf(format string, args ...any) {
+	l.log(slog.LevelDebug, format, args...)
+}
+
+type silentLogger struct{}
+
+// NewSilentLogger returns a logger which discards all logs
+func NewSilentLogger() Logger {
+	return silentLogger{}
+}
+
+func (silentLogger) Errorf(string, ...any)   {}
+func (silentLogger) Warningf(string, ...any) {}
+func (silentLogger) Infof(string, ...any)    {}
+func (silentLogger) Debugf(string, ...any)   {}
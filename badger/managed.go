@@ -0,0 +1,109 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	gobadger "github.com/dgraph-io/badger/v4"
+)
+
+// ErrManagedTxn returned by Update, Begin, Sequence, MergeOperator, Backup and Stream in managed mode
+var ErrManagedTxn = gobadger.ErrManagedTxn
+
+// errNotManaged returned by timestamp APIs when not in managed mode
+var errNotManaged = fmt.Errorf("managed mode required, call SetManagedMode before Connect")
+
+// SetManagedMode opens the database with OpenManaged, timestamps of transactions are set by the caller
+func (b *BadgerDB) SetManagedMode(managedMode bool) {
+	b.managedMode = managedMode
+}
+
+func (b *BadgerDB) connectManaged() (*gobadger.DB, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return nil, err
+	}
+	if !b.managedMode {
+		return nil, errNotManaged
+	}
+	return db, nil
+}
+
+// BeginAt begin a transaction reading at readTs, commit it with txn.CommitAt
+func (b *BadgerDB) BeginAt(readTs uint64, writable bool) (*Txn, error) {
+	db, err := b.connectManaged()
+	if err != nil {
+		return nil, err
+	}
+	return db.NewTransactionAt(readTs, writable), nil
+}
+
+// ViewAt run fn in a read-only transaction at readTs for point-in-time reads
+func (b *BadgerDB) ViewAt(readTs uint64, fn func(txn *Txn) error) error {
+	txn, err := b.BeginAt(readTs, false)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+	return fn(txn)
+}
+
+// UpdateAt run fn in a transaction reading at readTs and commit it at commitTs
+func (b *BadgerDB) UpdateAt(readTs, commitTs uint64, fn func(txn *Txn) error) error {
+	txn, err := b.BeginAt(readTs, true)
+	if err != nil {
+		return err
+	}
+	defer txn.Discard()
+
+	if err := fn(txn); err != nil {
+		return err
+	}
+	return txn.CommitAt(commitTs, nil)
+}
+
+// StreamAt exports keys with prefix as of readTs, like Stream
+func (b *BadgerDB) StreamAt(ctx context.Context, readTs uint64, prefix []byte, fn func(key, value []byte) error) error {
+	db, err := b.connectManaged()
+	if err != nil {
+		return err
+	}
+	return runStream(ctx, db.NewStreamAt(readTs), prefix, fn)
+}
+
+// SetDiscardTs allows versions below ts to be discarded by compaction
+func (b *BadgerDB) SetDiscardTs(ts uint64) error {
+	db, err := b.connectManaged()
+	if err != nil {
+		return err
+	}
+	db.SetDiscardTs(ts)
+	return nil
+}
+
+// KeyVersions returns all versions of key visible in txn, newest first, deleted versions included
+//
+//	eg: db.ViewAt(ts, func(txn *badger.Txn) error { versions, err := badger.KeyVersions(txn, key) })
+func KeyVersions(txn *Txn, key []byte) ([]*Entry, error) {
+	opts := gobadger.DefaultIteratorOptions
+	opts.AllVersions = true
+	opts.Prefix = key
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	var versions []*Entry
+	for it.Seek(key); it.ValidForPrefix(key); it.Next() {
+		item := it.Item()
+		if !bytes.Equal(item.Key(), key) {
+			break
+		}
+		entry, err := newEntry(item)
+		if err != nil {
+			return nil, err
+		}
+		entry.Deleted = item.IsDeletedOrExpired()
+		versions = append(versions, entry)
+	}
+	return versions, nil
+}
@@ -0,0 +1,104 @@
+package badger
+
+import (
+	"encoding/binary"
+	"time"
+
+	gobadger "github.com/dgraph-io/badger/v4"
+)
+
+type Sequence = gobadger.Sequence
+type MergeOperator = gobadger.MergeOperator
+type MergeFunc = gobadger.MergeFunc
+
+// Sequence returns a monotonically increasing sequence leasing bandwidth ids at a time, released on Close
+//
+//	eg: seq, err := db.Sequence([]byte("user_id"), 100)
+//	eg: id, err := seq.Next()
+func (b *BadgerDB) Sequence(key []byte, bandwidth uint64) (*Sequence, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	if b.managedMode {
+		return nil, ErrManagedTxn
+	}
+
+	seq, err := db.GetSequence(key, bandwidth)
+	if err != nil {
+		return nil, err
+	}
+	b.resMu.Lock()
+	b.sequences = append(b.sequences, seq)
+	b.resMu.Unlock()
+	return seq, nil
+}
+
+// MergeOperator returns a merge operator of key, values are merged by fn every interval, stopped on Close
+//
+//	eg: counter, err := db.MergeOperator([]byte("views"), badger.MergeInt64Add, time.Second)
+//	eg: counter.Add(badger.Int64ToBytes(1))
+func (b *BadgerDB) MergeOperator(key []byte, fn MergeFunc, interval time.Duration) (*MergeOperator, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	if b.managedMode {
+		return nil, ErrManagedTxn
+	}
+
+	op := db.GetMergeOperator(key, fn, interval)
+	b.resMu.Lock()
+	b.mergeOperators = append(b.mergeOperators, op)
+	b.resMu.Unlock()
+	return op, nil
+}
+
+// releaseResources stops merge operators and releases sequences
+func (b *BadgerDB) releaseResources() error {
+	b.resMu.Lock()
+	defer b.resMu.Unlock()
+
+	for _, op := range b.mergeOperators {
+		op.Stop()
+	}
+	b.mergeOperators = nil
+
+	var err error
+	for _, seq := range b.sequences {
+		if e := seq.Release(); e != nil && err == nil {
+			err = e
+		}
+	}
+	b.sequences = nil
+	return err
+}
+
+// Int64ToBytes 8 bytes big-endian, the encoding of MergeInt64Add
+func Int64ToBytes(v int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(v))
+	return buf
+}
+
+// BytesToInt64 decodes Int64ToBytes, invalid length returns 0
+func BytesToInt64(buf []byte) int64 {
+	if len(buf) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(buf))
+}
+
+// MergeInt64Add adds int64 values encoded by Int64ToBytes
+func MergeInt64Add(existing, value []byte) []byte {
+	return Int64ToBytes(BytesToInt64(existing) + BytesToInt64(value))
+}
+
+// MergeAppend appends value to existing
+func MergeAppend(existing, value []byte) []byte {
+	result := make([]byte, 0, len(existing)+len(value))
+	result = append(result, existing...)
+	return append(result, value...)
+}
@@ -0,0 +1,137 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+
+	gobadger "github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/pb"
+)
+
+// Buffer size of the Watch channel
+const WatchBufferSize = 64
+
+// Event change of a key
+type Event struct {
+	Key       []byte
+	Value     []byte
+	UserMeta  byte
+	ExpiresAt uint64
+	Version   uint64
+	Deleted   bool
+}
+
+// Watch subscribes changes of keys with prefixes, no prefix means all keys
+//
+//	the channel is closed when ctx is done or the database is closed
+//	the subscription is registered asynchronously, changes racing with Watch are delivered best-effort
+//	eg: events, err := db.Watch(ctx, []byte("user:"))
+//	eg: for event := range events {}
+func (b *BadgerDB) Watch(ctx context.Context, prefixes ...[]byte) (<-chan Event, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = [][]byte{{}}
+	}
+	matches := make([]pb.Match, len(prefixes))
+	for i, prefix := range prefixes {
+		matches[i] = pb.Match{Prefix: prefix}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	b.watchMu.Lock()
+	if b.watchClosed {
+		b.watchMu.Unlock()
+		cancel()
+		return nil, gobadger.ErrDBClosed
+	}
+	if b.watchCancels == nil {
+		b.watchCancels = make(map[uint64]context.CancelFunc)
+	}
+	b.watchID++
+	id := b.watchID
+	b.watchCancels[id] = cancel
+	b.watchWg.Add(1)
+	b.watchMu.Unlock()
+
+	events := make(chan Event, WatchBufferSize)
+	go func() {
+		defer b.watchWg.Done()
+		defer close(events)
+		defer func() {
+			cancel()
+			b.watchMu.Lock()
+			delete(b.watchCancels, id)
+			b.watchMu.Unlock()
+		}()
+
+		db.Subscribe(ctx, func(list *gobadger.KVList) error {
+			for _, kv := range list.Kv {
+				event := Event{
+					Key:       kv.Key,
+					Value:     kv.Value,
+					ExpiresAt: kv.ExpiresAt,
+					Version:   kv.Version,
+				}
+				if len(kv.Meta) > 0 {
+					event.UserMeta = kv.Meta[0]
+				}
+				// deletes are published with an empty value
+				if len(kv.Value) == 0 {
+					event.Deleted = isDeleted(db, kv.Key, kv.Version)
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		}, matches)
+	}()
+	return events, nil
+}
+
+// isDeleted checks whether version of key is a delete marker
+func isDeleted(db *gobadger.DB, key []byte, version uint64) bool {
+	deleted := false
+	db.View(func(txn *Txn) error {
+		opts := gobadger.DefaultIteratorOptions
+		opts.AllVersions = true
+		opts.PrefetchValues = false
+		opts.Prefix = key
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(key); it.ValidForPrefix(key); it.Next() {
+			item := it.Item()
+			if !bytes.Equal(item.Key(), key) {
+				break
+			}
+			if item.Version() == version {
+				deleted = item.IsDeletedOrExpired()
+				break
+			}
+		}
+		return nil
+	})
+	return deleted
+}
+
+// stopWatch cancels all subscriptions and waits for them
+func (b *BadgerDB) stopWatch() {
+	b.watchMu.Lock()
+	b.watchClosed = true
+	cancels := b.watchCancels
+	b.watchCancels = nil
+	b.watchMu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+	b.watchWg.Wait()
+}
@@ -0,0 +1,142 @@
+package boltdb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Max size of a transaction when compacting (64KB)
+const CompactTxMaxSize = 64 * 1024
+
+// Stats database statistics
+type Stats struct {
+	Size          int64
+	PageSize      int
+	FreePageN     int
+	PendingPageN  int
+	FreeAlloc     int
+	FreelistInuse int
+	TxN           int
+	OpenTxN       int
+	Buckets       map[string]BucketStats
+}
+
+// BucketStats statistics of a bucket, KeyN and BucketN count direct children only
+type BucketStats struct {
+	KeyN        int
+	BucketN     int
+	Depth       int
+	BranchPageN int
+	LeafPageN   int
+	BranchInuse int
+	LeafInuse   int
+}
+
+// Backup writes a consistent snapshot of the database to w
+//
+//	eg: db.Backup(httpResponseWriter)
+func (b *BoltDB) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := b.View(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// BackupToFile writes a consistent snapshot of the database to path
+func (b *BoltDB) BackupToFile(path string) error {
+	return b.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, b.fileMode())
+	})
+}
+
+// Compact copies the database into a new file at dstPath to reclaim free pages
+func (b *BoltDB) Compact(dstPath string) error {
+	src, err := b.Connect()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dstPath); err == nil {
+		return fmt.Errorf("compact destination %s already exists", dstPath)
+	}
+
+	options := &bolt.Options{Timeout: b.Timeout, FreelistType: b.boltOptions().FreelistType}
+	dst, err := bolt.Open(dstPath, b.fileMode(), options)
+	if err != nil {
+		return err
+	}
+	if err := bolt.Compact(dst, src, CompactTxMaxSize); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return err
+	}
+	return dst.Close()
+}
+
+// Stats returns page, freelist and per-bucket statistics, buckets are keyed by path joined with "/"
+func (b *BoltDB) Stats() (*Stats, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	dbStats := db.Stats()
+	stats := &Stats{
+		PageSize:      db.Info().PageSize,
+		FreePageN:     dbStats.FreePageN,
+		PendingPageN:  dbStats.PendingPageN,
+		FreeAlloc:     dbStats.FreeAlloc,
+		FreelistInuse: dbStats.FreelistInuse,
+		TxN:           dbStats.TxN,
+		OpenTxN:       dbStats.OpenTxN,
+		Buckets:       make(map[string]BucketStats),
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		stats.Size = tx.Size()
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			if isInternalBucket(name) {
+				return nil
+			}
+			return bucketStats([]string{string(name)}, bucket, stats.Buckets)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func bucketStats(path []string, bucket *bolt.Bucket, result map[string]BucketStats) error {
+	s := bucket.Stats()
+	stats := BucketStats{
+		Depth:       s.Depth,
+		BranchPageN: s.BranchPageN,
+		LeafPageN:   s.LeafPageN,
+		BranchInuse: s.BranchInuse,
+		LeafInuse:   s.LeafInuse,
+	}
+
+	err := bucket.ForEach(func(k, v []byte) error {
+		if v == nil {
+			stats.BucketN++
+			child := append(path[:len(path):len(path)], string(k))
+			return bucketStats(child, bucket.Bucket(k), result)
+		}
+		stats.KeyN++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result[strings.Join(path, "/")] = stats
+	return nil
+}
@@ -0,0 +1,665 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+)
+
+// BinaryCodec compact self-describing binary encoding, a subset of msgpack
+//
+//	supports nil, bool, integers, floats, string, []byte, slices, arrays, maps, pointers and structs
+//	structs are encoded as maps of exported fields, named by the binary tag or the field name
+//	encoding.BinaryMarshaler values (eg: time.Time) are encoded as bin
+//	eg: Name string `binary:"name"`
+//	eg: Cache []byte `binary:"-"`
+type BinaryCodec struct{}
+
+func (BinaryCodec) Marshal(v any) ([]byte, error) {
+	var e binaryEncoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (BinaryCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("binary codec: unmarshal into non-pointer %T", v)
+	}
+
+	d := binaryDecoder{data: data}
+	value, err := d.decode()
+	if err != nil {
+		return err
+	}
+	if d.pos != len(data) {
+		return fmt.Errorf("binary codec: %d trailing bytes", len(data)-d.pos)
+	}
+	return assignBinary(rv.Elem(), value)
+}
+
+var (
+	binaryMarshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// binaryMapEntry decoded map entry, maps keep the encoded order
+type binaryMapEntry struct {
+	key   any
+	value any
+}
+
+type binaryEncoder struct {
+	buf []byte
+}
+
+func (e *binaryEncoder) encode(rv reflect.Value) error {
+	if !rv.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+	}
+
+	if m, ok := binaryMarshaler(rv); ok {
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		e.encodeBytes(data)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(rv.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(float32(rv.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(rv.Float()))
+	case reflect.String:
+		e.encodeString(rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			e.encodeBytes(b)
+			return nil
+		}
+		e.encodeHeader(rv.Len(), 0x90, 0xdc, 0xdd)
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.encode(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return e.encodeMap(rv)
+	case reflect.Struct:
+		return e.encodeStruct(rv)
+	case reflect.Ptr, reflect.Interface:
+		return e.encode(rv.Elem())
+	default:
+		return fmt.Errorf("binary codec: unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+// binaryMarshaler returns the marshaler of rv, including MarshalBinary with a pointer receiver
+func binaryMarshaler(rv reflect.Value) (encoding.BinaryMarshaler, bool) {
+	if rv.Type().Implements(binaryMarshalerType) {
+		return rv.Interface().(encoding.BinaryMarshaler), true
+	}
+	if rv.Kind() == reflect.Ptr || !reflect.PointerTo(rv.Type()).Implements(binaryMarshalerType) {
+		return nil, false
+	}
+	if !rv.CanAddr() {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr.Elem()
+	}
+	return rv.Addr().Interface().(encoding.BinaryMarshaler), true
+}
+
+func (e *binaryEncoder) encodeInt(n int64) {
+	switch {
+	case n >= 0:
+		e.encodeUint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(n))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(n))
+	}
+}
+
+func (e *binaryEncoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, n)
+	}
+}
+
+func (e *binaryEncoder) encodeString(s string) {
+	switch n := len(s); {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *binaryEncoder) encodeBytes(b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xc5)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xc6)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+// encodeHeader writes the length of an array or map, fix holds up to 15 items
+func (e *binaryEncoder) encodeHeader(n int, fix, code16, code32 byte) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, code16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, code32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+// encodeMap sorts entries by encoded key so the same map always has the same bytes
+func (e *binaryEncoder) encodeMap(rv reflect.Value) error {
+	type entry struct {
+		key, value []byte
+	}
+
+	entries := make([]entry, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		var k, v binaryEncoder
+		if err := k.encode(iter.Key()); err != nil {
+			return err
+		}
+		if err := v.encode(iter.Value()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: k.buf, value: v.buf})
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return bytes.Compare(a.key, b.key)
+	})
+
+	e.encodeHeader(len(entries), 0x80, 0xde, 0xdf)
+	for _, entry := range entries {
+		e.buf = append(e.buf, entry.key...)
+		e.buf = append(e.buf, entry.value...)
+	}
+	return nil
+}
+
+func (e *binaryEncoder) encodeStruct(rv reflect.Value) error {
+	fields := binaryFields(rv.Type())
+	e.encodeHeader(len(fields), 0x80, 0xde, 0xdf)
+	for _, field := range fields {
+		e.encodeString(field.name)
+		if err := e.encode(rv.Field(field.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type binaryField struct {
+	name  string
+	index int
+}
+
+func binaryFields(t reflect.Type) []binaryField {
+	fields := make([]binaryField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("binary")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, binaryField{name: name, index: i})
+	}
+	return fields
+}
+
+type binaryDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *binaryDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, fmt.Errorf("binary codec: unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *binaryDecoder) uint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// decode reads one value as nil, bool, int64, uint64, float64, string, []byte, []any or []binaryMapEntry
+//
+//	uint64 is only used for values above math.MaxInt64
+func (d *binaryDecoder) decode() (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0xa0 && c <= 0xbf:
+		return d.string(int(c & 0x1f))
+	case c >= 0x90 && c <= 0x9f:
+		return d.array(int(c & 0x0f))
+	case c >= 0x80 && c <= 0x8f:
+		return d.mapEntries(int(c & 0x0f))
+	}
+
+	switch c := b[0]; c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign extend
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xca:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 0xcb:
+		n, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(n), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.string(int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.Clone(data), nil
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapEntries(int(n))
+	default:
+		return nil, fmt.Errorf("binary codec: unsupported format 0x%02x", c)
+	}
+}
+
+func (d *binaryDecoder) string(n int) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *binaryDecoder) array(n int) (any, error) {
+	// every item takes at least one byte
+	values := make([]any, 0, min(n, len(d.data)-d.pos))
+	for i := 0; i < n; i++ {
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (d *binaryDecoder) mapEntries(n int) (any, error) {
+	entries := make([]binaryMapEntry, 0, min(n, len(d.data)-d.pos))
+	for i := 0; i < n; i++ {
+		key, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, binaryMapEntry{key: key, value: value})
+	}
+	return entries, nil
+}
+
+// assignBinary stores a decoded value into rv
+func assignBinary(rv reflect.Value, value any) error {
+	if value == nil {
+		rv.SetZero()
+		return nil
+	}
+
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() && rv.Addr().Type().Implements(binaryUnmarshalerType) {
+		data, ok := value.([]byte)
+		if !ok {
+			return binaryMismatch(rv, value)
+		}
+		return rv.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return assignBinary(rv.Elem(), value)
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return binaryMismatch(rv, value)
+		}
+		natural, err := naturalBinary(value)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(natural))
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return binaryMismatch(rv, value)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := value.(int64)
+		if !ok || rv.OverflowInt(n) {
+			return binaryMismatch(rv, value)
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch v := value.(type) {
+		case int64:
+			if v < 0 {
+				return binaryMismatch(rv, value)
+			}
+			n = uint64(v)
+		case uint64:
+			n = v
+		default:
+			return binaryMismatch(rv, value)
+		}
+		if rv.OverflowUint(n) {
+			return binaryMismatch(rv, value)
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		switch v := value.(type) {
+		case float64:
+			rv.SetFloat(v)
+		case int64:
+			rv.SetFloat(float64(v))
+		case uint64:
+			rv.SetFloat(float64(v))
+		default:
+			return binaryMismatch(rv, value)
+		}
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			rv.SetString(v)
+		case []byte:
+			rv.SetString(string(v))
+		default:
+			return binaryMismatch(rv, value)
+		}
+	case reflect.Slice:
+		if b, ok := value.([]byte); ok && rv.Type().Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes(b)
+			return nil
+		}
+		values, ok := value.([]any)
+		if !ok {
+			return binaryMismatch(rv, value)
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := assignBinary(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+	case reflect.Array:
+		rv.SetZero()
+		if b, ok := value.([]byte); ok && rv.Type().Elem().Kind() == reflect.Uint8 {
+			reflect.Copy(rv, reflect.ValueOf(b))
+			return nil
+		}
+		values, ok := value.([]any)
+		if !ok {
+			return binaryMismatch(rv, value)
+		}
+		for i := 0; i < len(values) && i < rv.Len(); i++ {
+			if err := assignBinary(rv.Index(i), values[i]); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		entries, ok := value.([]binaryMapEntry)
+		if !ok {
+			return binaryMismatch(rv, value)
+		}
+		m := reflect.MakeMapWithSize(rv.Type(), len(entries))
+		for _, entry := range entries {
+			key := reflect.New(rv.Type().Key()).Elem()
+			if err := assignBinary(key, entry.key); err != nil {
+				return err
+			}
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := assignBinary(elem, entry.value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		rv.Set(m)
+	case reflect.Struct:
+		entries, ok := value.([]binaryMapEntry)
+		if !ok {
+			return binaryMismatch(rv, value)
+		}
+		fields := make(map[string]int)
+		for _, field := range binaryFields(rv.Type()) {
+			fields[field.name] = field.index
+		}
+		rv.SetZero()
+		for _, entry := range entries {
+			name, ok := entry.key.(string)
+			if !ok {
+				continue
+			}
+			// unknown fields are ignored
+			index, ok := fields[name]
+			if !ok {
+				continue
+			}
+			if err := assignBinary(rv.Field(index), entry.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("binary codec: unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+// naturalBinary converts a decoded value for an interface target, maps become map[string]any or map[any]any
+func naturalBinary(value any) (any, error) {
+	switch v := value.(type) {
+	case []any:
+		for i, item := range v {
+			natural, err := naturalBinary(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = natural
+		}
+		return v, nil
+	case []binaryMapEntry:
+		stringKeys := true
+		for _, entry := range v {
+			if _, ok := entry.key.(string); !ok {
+				stringKeys = false
+				break
+			}
+		}
+
+		if stringKeys {
+			m := make(map[string]any, len(v))
+			for _, entry := range v {
+				natural, err := naturalBinary(entry.value)
+				if err != nil {
+					return nil, err
+				}
+				m[entry.key.(string)] = natural
+			}
+			return m, nil
+		}
+
+		m := make(map[any]any, len(v))
+		for _, entry := range v {
+			key, err := naturalBinary(entry.key)
+			if err != nil {
+				return nil, err
+			}
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				return nil, fmt.Errorf("binary codec: unhashable map key %T", key)
+			}
+			natural, err := naturalBinary(entry.value)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = natural
+		}
+		return m, nil
+	}
+	return value, nil
+}
+
+func binaryMismatch(rv reflect.Value, value any) error {
+	return fmt.Errorf("binary codec: cannot decode %T into %s", value, rv.Type())
+}
@@ -2,7 +2,10 @@ package boltdb
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,30 +14,96 @@ import (
 
 type Tx = bolt.Tx
 
+type FreelistType = bolt.FreelistType
+
+const (
+	FreelistArrayType = bolt.FreelistArrayType
+	FreelistMapType   = bolt.FreelistMapType
+)
+
 type TxBucket struct {
+	db     *BoltDB
+	tx     *bolt.Tx
+	path   [][]byte
 	bucket *bolt.Bucket
 }
 
+// BoltDBOptions options of BoltDB
+//
+//	MaxBatchSize/MaxBatchDelay: limits of Batch, a batch is committed when either is reached, 0 means the default
+//	ReadOnly: open with a shared lock, writes fail and the TTL janitor is disabled
+//	NoSync/NoFreelistSync/NoGrowSync: skip fsync for bulk loads, unsafe on crash
+//	InitialMmapSize: initial mmap size in bytes, avoids remapping while readers are open
+//	FreelistType: FreelistArrayType or FreelistMapType
+//	FileMode: mode of the database file when created
+//	MmapFlags: flags passed to mmap, eg: syscall.MAP_POPULATE
+type BoltDBOptions struct {
+	MaxBatchSize    int
+	MaxBatchDelay   time.Duration
+	ReadOnly        bool
+	NoSync          bool
+	NoFreelistSync  bool
+	NoGrowSync      bool
+	InitialMmapSize int
+	FreelistType    FreelistType
+	FileMode        os.FileMode
+	MmapFlags       int
+}
+
 type BoltDB struct {
-	FileName string
-	Timeout  time.Duration
-	db       *bolt.DB
-	once     sync.Once
-	err      error
+	FileName        string
+	Timeout         time.Duration
+	JanitorInterval time.Duration
+	Options         *BoltDBOptions
+	db              *bolt.DB
+	once            sync.Once
+	err             error
+	janitorMu       sync.Mutex
+	janitorStop     chan struct{}
+	janitorDone     chan struct{}
 }
 
 type Bucket struct {
 	db   *BoltDB
-	name []byte
+	path [][]byte
 }
 
 // Default timeout value (15 seconds)
 const DefaultTimeout = 15 * time.Second
 
+// Default mode of the database file
+const DefaultFileMode os.FileMode = 0600
+
+// Default limits of Batch, same as bbolt
+const (
+	DefaultMaxBatchSize  = 1000
+	DefaultMaxBatchDelay = 10 * time.Millisecond
+)
+
+func NewBoltDBOptions() BoltDBOptions {
+	return BoltDBOptions{
+		MaxBatchSize:  DefaultMaxBatchSize,
+		MaxBatchDelay: DefaultMaxBatchDelay,
+		FreelistType:  FreelistArrayType,
+		FileMode:      DefaultFileMode,
+	}
+}
+
 func New(filename string) *BoltDB {
+	return NewWithOptions(filename, nil)
+}
+
+func NewWithOptions(filename string, options *BoltDBOptions) *BoltDB {
+	if options == nil {
+		opts := NewBoltDBOptions()
+		options = &opts
+	}
+
 	return &BoltDB{
-		FileName: filename,
-		Timeout:  DefaultTimeout,
+		FileName:        filename,
+		Timeout:         DefaultTimeout,
+		JanitorInterval: DefaultJanitorInterval,
+		Options:         options,
 	}
 }
 
@@ -44,6 +113,12 @@ func (b *BoltDB) SetTimeout(timeout time.Duration) *BoltDB {
 	return b
 }
 
+// SetJanitorInterval sets how often expired keys are purged, 0 disables the janitor
+func (b *BoltDB) SetJanitorInterval(interval time.Duration) *BoltDB {
+	b.JanitorInterval = interval
+	return b
+}
+
 // Connect open database
 func (b *BoltDB) Connect() (*bolt.DB, error) {
 	b.once.Do(func() {
@@ -51,16 +126,52 @@ func (b *BoltDB) Connect() (*bolt.DB, error) {
 			b.err = fmt.Errorf("filename is empty")
 			return
 		}
+		if b.Options == nil {
+			opts := NewBoltDBOptions()
+			b.Options = &opts
+		}
+
 		b.db, b.err = bolt.Open(
 			b.FileName,
-			0600,
-			&bolt.Options{Timeout: b.Timeout},
+			b.fileMode(),
+			b.boltOptions(),
 		)
+		if b.err != nil {
+			return
+		}
+		b.db.MaxBatchSize = cmp.Or(b.Options.MaxBatchSize, DefaultMaxBatchSize)
+		b.db.MaxBatchDelay = cmp.Or(b.Options.MaxBatchDelay, DefaultMaxBatchDelay)
+
+		if !b.Options.ReadOnly && hasTTL(b.db) {
+			b.startJanitor()
+		}
 	})
 	return b.db, b.err
 }
 
+func (b *BoltDB) fileMode() os.FileMode {
+	if b.Options == nil || b.Options.FileMode == 0 {
+		return DefaultFileMode
+	}
+	return b.Options.FileMode
+}
+
+func (b *BoltDB) boltOptions() *bolt.Options {
+	options := &bolt.Options{Timeout: b.Timeout}
+	if b.Options != nil {
+		options.ReadOnly = b.Options.ReadOnly
+		options.NoSync = b.Options.NoSync
+		options.NoFreelistSync = b.Options.NoFreelistSync
+		options.NoGrowSync = b.Options.NoGrowSync
+		options.InitialMmapSize = b.Options.InitialMmapSize
+		options.FreelistType = b.Options.FreelistType
+		options.MmapFlags = b.Options.MmapFlags
+	}
+	return options
+}
+
 func (b *BoltDB) Close() error {
+	b.stopJanitor()
 	if b.db == nil {
 		return nil
 	}
@@ -69,11 +180,18 @@ func (b *BoltDB) Close() error {
 	return err
 }
 
-// Bucket returns a bucket instance
-func (b *BoltDB) Bucket(name string) *Bucket {
+// Bucket returns a bucket instance, nested buckets are addressed by path
+//
+//	eg: db.Bucket("users")
+//	eg: db.Bucket("tenants", "acme", "users")
+func (b *BoltDB) Bucket(path ...string) *Bucket {
+	bucketPath := make([][]byte, len(path))
+	for i, name := range path {
+		bucketPath[i] = []byte(name)
+	}
 	return &Bucket{
 		db:   b,
-		name: []byte(name),
+		path: bucketPath,
 	}
 }
 
@@ -115,6 +233,17 @@ func (b *BoltDB) Update(fn func(*Tx) error) error {
 	return db.Update(fn)
 }
 
+// Batch calls fn in a write transaction shared with concurrent Batch calls
+//
+//	fn may be called more than once and must be idempotent
+func (b *BoltDB) Batch(fn func(*Tx) error) error {
+	db, err := b.Connect()
+	if err != nil {
+		return err
+	}
+	return db.Batch(fn)
+}
+
 func (b *BoltDB) ListBuckets() ([]string, error) {
 	var result []string
 
@@ -125,6 +254,9 @@ func (b *BoltDB) ListBuckets() ([]string, error) {
 
 	err = db.View(func(tx *bolt.Tx) error {
 		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if isInternalBucket(name) {
+				return nil
+			}
 			result = append(result, string(name))
 			return nil
 		})
@@ -133,50 +265,192 @@ func (b *BoltDB) ListBuckets() ([]string, error) {
 	return result, err
 }
 
-// Create creates bucket if not exists
-func (b *Bucket) Create() error {
-	if len(b.name) == 0 {
+// WalkBuckets iterates all buckets recursively, parents before children
+func (b *BoltDB) WalkBuckets(fn func(path []string) error) error {
+	return b.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			if isInternalBucket(name) {
+				return nil
+			}
+			return walkBucket([]string{string(name)}, bucket, fn)
+		})
+	})
+}
+
+func walkBucket(path []string, bucket *bolt.Bucket, fn func(path []string) error) error {
+	if err := fn(path); err != nil {
+		return err
+	}
+	return bucket.ForEachBucket(func(name []byte) error {
+		child := append(path[:len(path):len(path)], string(name))
+		return walkBucket(child, bucket.Bucket(name), fn)
+	})
+}
+
+// ListBucketsRecursive returns paths of all buckets
+func (b *BoltDB) ListBucketsRecursive() ([][]string, error) {
+	var result [][]string
+	err := b.WalkBuckets(func(path []string) error {
+		result = append(result, path)
+		return nil
+	})
+	return result, err
+}
+
+// DeleteBucket deletes bucket and its children by path
+func (b *BoltDB) DeleteBucket(path ...string) error {
+	return b.Bucket(path...).Drop()
+}
+
+// Path returns bucket path
+func (b *Bucket) Path() []string {
+	path := make([]string, len(b.path))
+	for i, name := range b.path {
+		path[i] = string(name)
+	}
+	return path
+}
+
+func (b *Bucket) String() string {
+	return strings.Join(b.Path(), "/")
+}
+
+// Bucket returns a child bucket instance
+func (b *Bucket) Bucket(path ...string) *Bucket {
+	return b.db.Bucket(append(b.Path(), path...)...)
+}
+
+func (b *Bucket) validate() error {
+	if len(b.path) == 0 {
 		return fmt.Errorf("bucket name is empty")
 	}
+	for _, name := range b.path {
+		if len(name) == 0 {
+			return fmt.Errorf("bucket name is empty")
+		}
+	}
+	if isInternalBucket(b.path[0]) {
+		return fmt.Errorf("bucket prefix %q is reserved", internalBucketPrefix)
+	}
+	return nil
+}
 
-	return b.db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(b.name)
+func (b *Bucket) lookup(tx *bolt.Tx) *bolt.Bucket {
+	bucket := tx.Bucket(b.path[0])
+	for _, name := range b.path[1:] {
+		if bucket == nil {
+			return nil
+		}
+		bucket = bucket.Bucket(name)
+	}
+	return bucket
+}
+
+func (b *Bucket) resolve(tx *bolt.Tx) (*TxBucket, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	bucket := b.lookup(tx)
+	if bucket == nil {
+		return nil, fmt.Errorf("bucket %q not found", b.String())
+	}
+	return &TxBucket{db: b.db, tx: tx, path: b.path, bucket: bucket}, nil
+}
+
+// Create creates bucket and its parents if not exists
+func (b *Bucket) Create() error {
+	if err := b.validate(); err != nil {
 		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.path[0])
+		if err != nil {
+			return err
+		}
+		for _, name := range b.path[1:] {
+			bucket, err = bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
 // Exists checks if bucket exists
 func (b *Bucket) Exists() (bool, error) {
-	if len(b.name) == 0 {
-		return false, fmt.Errorf("bucket name is empty")
+	if err := b.validate(); err != nil {
+		return false, err
 	}
 
 	var ok bool
 	err := b.db.View(func(tx *bolt.Tx) error {
-		ok = tx.Bucket(b.name) != nil
+		ok = b.lookup(tx) != nil
 		return nil
 	})
 
 	return ok, err
 }
 
+// Drop deletes bucket and its children
+func (b *Bucket) Drop() error {
+	if err := b.validate(); err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := b.lookup(tx)
+		if err := dropIndexes(tx, b.path, bucket); err != nil {
+			return err
+		}
+		if err := dropTTL(tx, b.path, bucket); err != nil {
+			return err
+		}
+
+		last := len(b.path) - 1
+		if last == 0 {
+			return tx.DeleteBucket(b.path[0])
+		}
+
+		parent, err := (&Bucket{db: b.db, path: b.path[:last]}).resolve(tx)
+		if err != nil {
+			return err
+		}
+		return parent.DeleteBucket(b.path[last])
+	})
+}
+
+// ListBuckets returns names of child buckets
+func (b *Bucket) ListBuckets() ([]string, error) {
+	var result []string
+	err := b.View(func(tx *TxBucket) error {
+		return tx.ForEachBucket(func(name []byte) error {
+			result = append(result, string(name))
+			return nil
+		})
+	})
+	return result, err
+}
+
 func (b *Bucket) View(fn func(*TxBucket) error) error {
 	return b.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(b.name)
-		if bucket == nil {
-			return fmt.Errorf("bucket %q not found", b.name)
+		bucket, err := b.resolve(tx)
+		if err != nil {
+			return err
 		}
-		return fn(&TxBucket{bucket: bucket})
+		return fn(bucket)
 	})
 }
 
 func (b *Bucket) Update(fn func(*TxBucket) error) error {
 	return b.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(b.name)
-		if bucket == nil {
-			return fmt.Errorf("bucket %q not found", b.name)
+		bucket, err := b.resolve(tx)
+		if err != nil {
+			return err
 		}
-		return fn(&TxBucket{bucket: bucket})
+		return fn(bucket)
 	})
 }
 
@@ -187,6 +461,29 @@ func (b *Bucket) Put(key, value []byte) error {
 	})
 }
 
+// BatchPut stores key-value through BoltDB.Batch, for many concurrent writers
+func (b *Bucket) BatchPut(key, value []byte) error {
+	return b.db.Batch(func(tx *bolt.Tx) error {
+		bucket, err := b.resolve(tx)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
+
+// PutMany stores all key-values in one transaction
+func (b *Bucket) PutMany(items map[string][]byte) error {
+	return b.Update(func(tx *TxBucket) error {
+		for k, v := range items {
+			if err := tx.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // PutString helper
 func (b *Bucket) PutString(key, value string) error {
 	return b.Put([]byte(key), []byte(value))
@@ -225,28 +522,22 @@ func (b *Bucket) Delete(key []byte) error {
 	})
 }
 
-// ForEach iterates prefix keys
+// ForEach iterates prefix keys, nested buckets are skipped
 func (b *Bucket) ForEach(prefix []byte, fn func(k, v []byte) error) error {
-	return b.View(func(tx *TxBucket) error {
-		c := tx.Cursor()
-		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
-			key := bytes.Clone(k)
-			val := bytes.Clone(v)
-			if err := fn(key, val); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	return b.Scan(ScanOptions{Prefix: prefix}, fn)
 }
 
 // DeletePrefix deletes keys by prefix
 func (b *Bucket) DeletePrefix(prefix []byte) error {
 	return b.Update(func(tx *TxBucket) error {
 		c := tx.Cursor()
-		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); {
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); {
 			key := bytes.Clone(k)
-			k, _ = c.Next()
+			isBucket := v == nil
+			k, v = c.Next()
+			if isBucket {
+				continue
+			}
 			if err := tx.Delete(key); err != nil {
 				return err
 			}
@@ -255,35 +546,141 @@ func (b *Bucket) DeletePrefix(prefix []byte) error {
 	})
 }
 
-// ListKeyValues loads entire bucket
+// ListKeyValues loads entire bucket, nested buckets are skipped
 func (b *Bucket) ListKeyValues() (map[string]string, error) {
 	res := make(map[string]string)
 	err := b.View(func(tx *TxBucket) error {
-		c := tx.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
+		return tx.Scan(ScanOptions{}, func(k, v []byte) error {
 			res[string(k)] = string(v)
-		}
-		return nil
+			return nil
+		})
 	})
 	return res, err
 }
 
+// Get returns value, nil if not exists or expired
 func (b *TxBucket) Get(key []byte) []byte {
 	v := b.bucket.Get(key)
-	if v == nil {
+	if v == nil || !b.live(key) {
 		return nil
 	}
 	return bytes.Clone(v)
 }
 
+// Put stores key-value and clears its TTL
 func (b *TxBucket) Put(key, value []byte) error {
-	return b.bucket.Put(key, value)
+	if err := b.bucket.Put(key, value); err != nil {
+		return err
+	}
+	return b.clearTTL(key)
 }
 
 func (b *TxBucket) Delete(key []byte) error {
-	return b.bucket.Delete(key)
+	if err := b.bucket.Delete(key); err != nil {
+		return err
+	}
+	return b.clearTTL(key)
 }
 
 func (b *TxBucket) Cursor() *bolt.Cursor {
 	return b.bucket.Cursor()
 }
+
+// Bucket returns a child bucket, nil if not exists
+func (b *TxBucket) Bucket(name []byte) *TxBucket {
+	bucket := b.bucket.Bucket(name)
+	if bucket == nil {
+		return nil
+	}
+	return b.child(name, bucket)
+}
+
+func (b *TxBucket) child(name []byte, bucket *bolt.Bucket) *TxBucket {
+	path := append(b.path[:len(b.path):len(b.path)], bytes.Clone(name))
+	return &TxBucket{db: b.db, tx: b.tx, path: path, bucket: bucket}
+}
+
+// CreateBucket creates a child bucket, fails if exists
+func (b *TxBucket) CreateBucket(name []byte) (*TxBucket, error) {
+	bucket, err := b.bucket.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+	return b.child(name, bucket), nil
+}
+
+// CreateBucketIfNotExists creates a child bucket if not exists
+func (b *TxBucket) CreateBucketIfNotExists(name []byte) (*TxBucket, error) {
+	bucket, err := b.bucket.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, err
+	}
+	return b.child(name, bucket), nil
+}
+
+// DeleteBucket deletes a child bucket and its children
+func (b *TxBucket) DeleteBucket(name []byte) error {
+	if b.tx != nil {
+		child := b.child(name, b.bucket.Bucket(name))
+		if err := dropIndexes(b.tx, child.path, child.bucket); err != nil {
+			return err
+		}
+		if err := dropTTL(b.tx, child.path, child.bucket); err != nil {
+			return err
+		}
+	}
+	return b.bucket.DeleteBucket(name)
+}
+
+// ForEachBucket iterates names of child buckets
+func (b *TxBucket) ForEachBucket(fn func(name []byte) error) error {
+	return b.bucket.ForEachBucket(fn)
+}
+
+// NextSequence returns an autoincrementing integer of the bucket
+func (b *TxBucket) NextSequence() (uint64, error) {
+	return b.bucket.NextSequence()
+}
+
+// Sequence returns the current sequence of the bucket
+func (b *TxBucket) Sequence() uint64 {
+	return b.bucket.Sequence()
+}
+
+// SetSequence updates the sequence of the bucket
+func (b *TxBucket) SetSequence(v uint64) error {
+	return b.bucket.SetSequence(v)
+}
+
+// Append stores value under the next sequence as 8 bytes big-endian key
+func (b *TxBucket) Append(value []byte) (uint64, error) {
+	id, err := b.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	return id, b.Put(Itob(id), value)
+}
+
+// NextID returns the next sequence of the bucket
+func (b *Bucket) NextID() (uint64, error) {
+	var id uint64
+	err := b.Update(func(tx *TxBucket) error {
+		var err error
+		id, err = tx.NextSequence()
+		return err
+	})
+	return id, err
+}
+
+// Append stores value under the next sequence, returns the sequence
+//
+//	eg: id, err := db.Bucket("logs").Append([]byte("message"))
+func (b *Bucket) Append(value []byte) (uint64, error) {
+	var id uint64
+	err := b.Update(func(tx *TxBucket) error {
+		var err error
+		id, err = tx.Append(value)
+		return err
+	})
+	return id, err
+}
@@ -0,0 +1,193 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encode values of TypedBucket
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// KeyEncoder encode keys of TypedBucket
+//
+//	the encoded bytes should keep the sort order of keys
+type KeyEncoder[K any] interface {
+	EncodeKey(key K) ([]byte, error)
+	DecodeKey(data []byte) (K, error)
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(v); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// ProtoCodec protobuf encoding, values must implement proto.Message
+type ProtoCodec struct{}
+
+func (ProtoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (ProtoCodec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+
+	// v is a pointer to a message pointer, eg: **pb.User
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Ptr {
+		msg := reflect.New(rv.Elem().Type().Elem())
+		if m, ok := msg.Interface().(proto.Message); ok {
+			if err := proto.Unmarshal(data, m); err != nil {
+				return err
+			}
+			rv.Elem().Set(msg)
+			return nil
+		}
+	}
+	return fmt.Errorf("%T does not implement proto.Message", v)
+}
+
+type StringKey struct{}
+
+func (StringKey) EncodeKey(key string) ([]byte, error) {
+	return []byte(key), nil
+}
+
+func (StringKey) DecodeKey(data []byte) (string, error) {
+	return string(data), nil
+}
+
+type BytesKey struct{}
+
+func (BytesKey) EncodeKey(key []byte) ([]byte, error) {
+	return key, nil
+}
+
+func (BytesKey) DecodeKey(data []byte) ([]byte, error) {
+	return bytes.Clone(data), nil
+}
+
+// Uint64Key 8 bytes big-endian
+type Uint64Key struct{}
+
+func (Uint64Key) EncodeKey(key uint64) ([]byte, error) {
+	return Itob(key), nil
+}
+
+func (Uint64Key) DecodeKey(data []byte) (uint64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid uint64 key length: %d", len(data))
+	}
+	return Btoi(data), nil
+}
+
+// Int64Key 8 bytes big-endian with the sign bit flipped, negative keys sort first
+type Int64Key struct{}
+
+func (Int64Key) EncodeKey(key int64) ([]byte, error) {
+	return Itob(uint64(key) ^ (1 << 63)), nil
+}
+
+func (Int64Key) DecodeKey(data []byte) (int64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid int64 key length: %d", len(data))
+	}
+	return int64(Btoi(data) ^ (1 << 63)), nil
+}
+
+// TimeKey unix seconds encoded as Int64Key followed by 4 bytes of nanoseconds, decoded in UTC
+//
+//	covers the full range of time.Time, including the zero time
+type TimeKey struct{}
+
+func (TimeKey) EncodeKey(key time.Time) ([]byte, error) {
+	sec, err := Int64Key{}.EncodeKey(key.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return binary.BigEndian.AppendUint32(sec, uint32(key.Nanosecond())), nil
+}
+
+func (TimeKey) DecodeKey(data []byte) (time.Time, error) {
+	if len(data) != 12 {
+		return time.Time{}, fmt.Errorf("invalid time key length: %d", len(data))
+	}
+	sec, err := Int64Key{}.DecodeKey(data[:8])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, int64(binary.BigEndian.Uint32(data[8:]))).UTC(), nil
+}
+
+// Float64Key IEEE 754 bits reordered so that byte order matches numeric order
+type Float64Key struct{}
+
+func (Float64Key) EncodeKey(key float64) ([]byte, error) {
+	bits := math.Float64bits(key)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return Itob(bits), nil
+}
+
+func (Float64Key) DecodeKey(data []byte) (float64, error) {
+	if len(data) != 8 {
+		return 0, fmt.Errorf("invalid float64 key length: %d", len(data))
+	}
+	bits := Btoi(data)
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits), nil
+}
+
+// Itob uint64 to 8 bytes big-endian
+func Itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// Btoi 8 bytes big-endian to uint64
+func Btoi(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,255 @@
+package boltdb
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// __qdb_index / encoded bucket path / index name / escaped value+key => key
+var indexBucket = []byte(internalBucketPrefix + "index")
+
+// escaped index values end with 0x00 0x01, entries of greater values sort after 0x00 0x02
+var (
+	indexTerminator = []byte{0x00, 0x01}
+	indexAfter      = []byte{0x00, 0x02}
+)
+
+// IndexFunc extracts index values from a value, nil means not indexed
+type IndexFunc[V any] func(value V) [][]byte
+
+type typedIndex[V any] struct {
+	name string
+	fn   IndexFunc[V]
+}
+
+// AddIndex registers a secondary index, maintained by Put and Delete of the typed bucket
+//
+//	eg: users.AddIndex("email", func(u User) [][]byte { return [][]byte{[]byte(u.Email)} })
+//	writes through the untyped Bucket bypass indexes, call Reindex to rebuild
+func (t *TypedBucket[K, V]) AddIndex(name string, fn IndexFunc[V]) *TypedBucket[K, V] {
+	for i, idx := range t.indexes {
+		if idx.name == name {
+			t.indexes[i].fn = fn
+			return t
+		}
+	}
+	t.indexes = append(t.indexes, typedIndex[V]{name: name, fn: fn})
+	return t
+}
+
+func (t *TypedBucket[K, V]) index(name string) (*typedIndex[V], error) {
+	for i := range t.indexes {
+		if t.indexes[i].name == name {
+			return &t.indexes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("index %q not found", name)
+}
+
+// indexBucketOf returns bucket of an index, nil if not exists and create is false
+func indexBucketOf(tx *bolt.Tx, path [][]byte, name string, create bool) (*bolt.Bucket, error) {
+	// same path encoding as ttl ids
+	keys := [][]byte{indexBucket, ttlID(path, nil), []byte(name)}
+	if !create {
+		bucket := tx.Bucket(keys[0])
+		for _, key := range keys[1:] {
+			if bucket == nil {
+				return nil, nil
+			}
+			bucket = bucket.Bucket(key)
+		}
+		return bucket, nil
+	}
+
+	bucket, err := tx.CreateBucketIfNotExists(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys[1:] {
+		if bucket, err = bucket.CreateBucketIfNotExists(key); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+// escapeIndexValue escapes 0x00 as 0x00 0xff, the byte order of values is kept
+func escapeIndexValue(value []byte) []byte {
+	escaped := make([]byte, 0, len(value)+2)
+	for _, c := range value {
+		escaped = append(escaped, c)
+		if c == 0x00 {
+			escaped = append(escaped, 0xff)
+		}
+	}
+	return escaped
+}
+
+// indexBound returns the first entry of value, or the first entry after value
+func indexBound(value []byte, after bool) []byte {
+	if after {
+		return append(escapeIndexValue(value), indexAfter...)
+	}
+	return append(escapeIndexValue(value), indexTerminator...)
+}
+
+// indexEntry entries are ordered by value then key
+func indexEntry(value, key []byte) []byte {
+	return append(indexBound(value, false), key...)
+}
+
+// dropIndexes deletes index buckets of the bucket at path and its children
+func dropIndexes(tx *bolt.Tx, path [][]byte, bucket *bolt.Bucket) error {
+	root := tx.Bucket(indexBucket)
+	if root == nil || bucket == nil {
+		return nil
+	}
+
+	if id := ttlID(path, nil); root.Bucket(id) != nil {
+		if err := root.DeleteBucket(id); err != nil {
+			return err
+		}
+	}
+	return bucket.ForEachBucket(func(name []byte) error {
+		child := append(path[:len(path):len(path)], name)
+		return dropIndexes(tx, child, bucket.Bucket(name))
+	})
+}
+
+// unindex removes index entries of the current value of k
+func (t *TypedBucket[K, V]) unindex(tx *TxBucket, k []byte) error {
+	if len(t.indexes) == 0 {
+		return nil
+	}
+	v := tx.bucket.Get(k)
+	if v == nil {
+		return nil
+	}
+	var old V
+	if err := t.codec.Unmarshal(v, &old); err != nil {
+		return err
+	}
+
+	for _, idx := range t.indexes {
+		bucket, err := indexBucketOf(tx.tx, tx.path, idx.name, false)
+		if err != nil || bucket == nil {
+			continue
+		}
+		for _, value := range idx.fn(old) {
+			if err := bucket.Delete(indexEntry(value, k)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reindex adds index entries of value
+func (t *TypedBucket[K, V]) reindex(tx *TxBucket, k []byte, value V) error {
+	for _, idx := range t.indexes {
+		values := idx.fn(value)
+		if len(values) == 0 {
+			continue
+		}
+		bucket, err := indexBucketOf(tx.tx, tx.path, idx.name, true)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			if err := bucket.Put(indexEntry(value, k), k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FindBy returns values whose index value equals value, in key order
+func (t *TypedBucket[K, V]) FindBy(index string, value []byte) ([]V, error) {
+	var result []V
+	err := t.scanIndex(index, ScanOptions{Prefix: indexBound(value, false)}, func(_ K, v V) error {
+		result = append(result, v)
+		return nil
+	})
+	return result, err
+}
+
+// FindByRange iterates values whose index value is in range, ordered by index value
+//
+//	Start/End/Prefix are compared with index values, Cursor is not supported
+func (t *TypedBucket[K, V]) FindByRange(index string, opts ScanOptions, fn func(key K, value V) error) error {
+	opts.Cursor = ""
+	if opts.Prefix != nil {
+		opts.Prefix = escapeIndexValue(opts.Prefix)
+	}
+	if opts.Start != nil {
+		opts.Start, opts.StartExclusive = indexBound(opts.Start, opts.StartExclusive), false
+	}
+	if opts.End != nil {
+		opts.End, opts.EndInclusive = indexBound(opts.End, opts.EndInclusive), false
+	}
+	return t.scanIndex(index, opts, fn)
+}
+
+// scanIndex scans entries of an index, opts are compared with encoded entries
+func (t *TypedBucket[K, V]) scanIndex(index string, opts ScanOptions, fn func(key K, value V) error) error {
+	idx, err := t.index(index)
+	if err != nil {
+		return err
+	}
+
+	return t.bucket.View(func(tx *TxBucket) error {
+		bucket, err := indexBucketOf(tx.tx, tx.path, index, false)
+		if err != nil || bucket == nil {
+			return err
+		}
+
+		return (&TxBucket{bucket: bucket}).Scan(opts, func(entry, k []byte) error {
+			v := tx.Get(k)
+			// stale entry, the key was removed or expired
+			if v == nil {
+				return nil
+			}
+			key, value, err := t.decode(k, v)
+			if err != nil {
+				return err
+			}
+			// stale entry, the value was rewritten without the index
+			if !slices.ContainsFunc(idx.fn(value), func(indexValue []byte) bool {
+				return bytes.Equal(indexEntry(indexValue, k), entry)
+			}) {
+				return nil
+			}
+			return fn(key, value)
+		})
+	})
+}
+
+// Reindex rebuilds all registered indexes of the bucket
+func (t *TypedBucket[K, V]) Reindex() error {
+	return t.bucket.Update(func(tx *TxBucket) error {
+		if root := tx.tx.Bucket(indexBucket); root != nil {
+			if indexes := root.Bucket(ttlID(tx.path, nil)); indexes != nil {
+				for _, idx := range t.indexes {
+					if indexes.Bucket([]byte(idx.name)) == nil {
+						continue
+					}
+					if err := indexes.DeleteBucket([]byte(idx.name)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return tx.Scan(ScanOptions{}, func(k, v []byte) error {
+			var value V
+			if err := t.codec.Unmarshal(v, &value); err != nil {
+				return err
+			}
+			return t.reindex(tx, bytes.Clone(k), value)
+		})
+	})
+}
@@ -0,0 +1,220 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// DefaultPageSize page size of Bucket.Page when Limit is 0
+const DefaultPageSize = 100
+
+// ScanOptions range scan options
+//
+//	Start/End: key bounds, nil means unbounded, default range is [Start, End)
+//	StartExclusive/EndInclusive: change the bound inclusiveness
+//	Prefix: only keys with prefix
+//	Reverse: iterate from the end of the range
+//	Limit: max items, 0 means no limit
+//	Cursor: token of Page.Next, iteration resumes after it
+type ScanOptions struct {
+	Start          []byte
+	End            []byte
+	StartExclusive bool
+	EndInclusive   bool
+	Prefix         []byte
+	Reverse        bool
+	Limit          int
+	Cursor         string
+}
+
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// Page a page of scan results, Next is empty on the last page
+type Page struct {
+	Items []KeyValue
+	Next  string
+}
+
+// EncodeCursor encode a key as pagination token
+func EncodeCursor(key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// DecodeCursor decode a pagination token
+func DecodeCursor(cursor string) ([]byte, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+	return key, nil
+}
+
+type scanRange struct {
+	lo, hi   []byte
+	loExcl   bool
+	hiIncl   bool
+	prefix   []byte
+	reverse  bool
+	hasLimit bool
+	limit    int
+}
+
+func newScanRange(opts ScanOptions) (*scanRange, error) {
+	r := &scanRange{
+		lo:       opts.Start,
+		hi:       opts.End,
+		loExcl:   opts.StartExclusive,
+		hiIncl:   opts.EndInclusive,
+		prefix:   opts.Prefix,
+		reverse:  opts.Reverse,
+		hasLimit: opts.Limit > 0,
+		limit:    opts.Limit,
+	}
+
+	if opts.Cursor != "" {
+		key, err := DecodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if r.reverse {
+			r.hi, r.hiIncl = key, false
+		} else {
+			r.lo, r.loExcl = key, true
+		}
+	}
+	return r, nil
+}
+
+// prefixEnd returns the first key after all keys with prefix, nil if unbounded
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// below key is before the start of the range
+func (r *scanRange) below(k []byte) bool {
+	if r.lo != nil {
+		cmp := bytes.Compare(k, r.lo)
+		if cmp < 0 || (cmp == 0 && r.loExcl) {
+			return true
+		}
+	}
+	return r.prefix != nil && bytes.Compare(k, r.prefix) < 0
+}
+
+// above key is after the end of the range
+func (r *scanRange) above(k []byte) bool {
+	if r.hi != nil {
+		cmp := bytes.Compare(k, r.hi)
+		if cmp > 0 || (cmp == 0 && !r.hiIncl) {
+			return true
+		}
+	}
+	return r.prefix != nil && bytes.Compare(k, r.prefix) > 0 && !bytes.HasPrefix(k, r.prefix)
+}
+
+// Scan iterates keys in range, nested buckets and expired keys are skipped
+//
+//	k and v are only valid during the transaction
+func (b *TxBucket) Scan(opts ScanOptions, fn func(k, v []byte) error) error {
+	r, err := newScanRange(opts)
+	if err != nil {
+		return err
+	}
+
+	c := b.Cursor()
+	var k, v []byte
+	if r.reverse {
+		upper := r.hi
+		if r.prefix != nil {
+			if end := prefixEnd(r.prefix); end != nil && (upper == nil || bytes.Compare(end, upper) < 0) {
+				upper = end
+			}
+		}
+		if upper != nil {
+			k, v = c.Seek(upper)
+		}
+		if k == nil {
+			k, v = c.Last()
+		}
+		for k != nil && r.above(k) {
+			k, v = c.Prev()
+		}
+	} else {
+		seek := r.lo
+		if r.prefix != nil && bytes.Compare(r.prefix, seek) > 0 {
+			seek = r.prefix
+		}
+		k, v = c.Seek(seek)
+		for k != nil && r.below(k) {
+			k, v = c.Next()
+		}
+	}
+
+	count := 0
+	for k != nil {
+		if r.reverse && r.below(k) || !r.reverse && r.above(k) {
+			break
+		}
+		if r.hasLimit && count >= r.limit {
+			break
+		}
+
+		if v != nil && b.live(k) {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+			count++
+		}
+
+		if r.reverse {
+			k, v = c.Prev()
+		} else {
+			k, v = c.Next()
+		}
+	}
+	return nil
+}
+
+// Scan iterates keys in range, nested buckets are skipped
+func (b *Bucket) Scan(opts ScanOptions, fn func(k, v []byte) error) error {
+	return b.View(func(tx *TxBucket) error {
+		return tx.Scan(opts, func(k, v []byte) error {
+			return fn(bytes.Clone(k), bytes.Clone(v))
+		})
+	})
+}
+
+// Page returns one page of the range, pass Page.Next as Cursor to get the next page
+func (b *Bucket) Page(opts ScanOptions) (*Page, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	opts.Limit = limit + 1
+
+	page := &Page{Items: make([]KeyValue, 0, limit)}
+	err := b.Scan(opts, func(k, v []byte) error {
+		page.Items = append(page.Items, KeyValue{Key: k, Value: v})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.Next = EncodeCursor(page.Items[limit-1].Key)
+	}
+	return page, nil
+}
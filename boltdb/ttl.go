@@ -0,0 +1,331 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Default interval of the expiration janitor (1 minute)
+const DefaultJanitorInterval = time.Minute
+
+// Max expired keys deleted in one transaction
+const JanitorBatchSize = 1000
+
+// internal buckets are hidden from ListBuckets and WalkBuckets, the prefix is reserved for top-level buckets
+const internalBucketPrefix = "__qdb_"
+
+var (
+	ttlBucket       = []byte(internalBucketPrefix + "ttl")
+	ttlKeysBucket   = []byte("keys")
+	ttlExpiryBucket = []byte("expiry")
+)
+
+func isInternalBucket(name []byte) bool {
+	return strings.HasPrefix(string(name), internalBucketPrefix)
+}
+
+func hasTTL(db *bolt.DB) bool {
+	found := false
+	db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(ttlBucket) != nil
+		return nil
+	})
+	return found
+}
+
+// ttlID encodes bucket path and key as a unique id
+func ttlID(path [][]byte, key []byte) []byte {
+	id := binary.AppendUvarint(nil, uint64(len(path)))
+	for _, name := range path {
+		id = binary.AppendUvarint(id, uint64(len(name)))
+		id = append(id, name...)
+	}
+	return append(id, key...)
+}
+
+// parseTTLID decodes bucket path and key from id
+func parseTTLID(id []byte) ([][]byte, []byte, error) {
+	n, size := binary.Uvarint(id)
+	if size <= 0 {
+		return nil, nil, fmt.Errorf("invalid ttl id")
+	}
+	id = id[size:]
+
+	path := make([][]byte, 0, n)
+	for i := uint64(0); i < n; i++ {
+		l, size := binary.Uvarint(id)
+		if size <= 0 || uint64(len(id)-size) < l {
+			return nil, nil, fmt.Errorf("invalid ttl id")
+		}
+		path = append(path, id[size:size+int(l)])
+		id = id[size+int(l):]
+	}
+	return path, id, nil
+}
+
+func (b *TxBucket) expiry(key []byte) (time.Time, bool) {
+	if b.tx == nil {
+		return time.Time{}, false
+	}
+	ttl := b.tx.Bucket(ttlBucket)
+	if ttl == nil {
+		return time.Time{}, false
+	}
+	v := ttl.Bucket(ttlKeysBucket).Get(ttlID(b.path, key))
+	if len(v) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(Btoi(v))), true
+}
+
+// live key has no TTL or is not expired yet
+func (b *TxBucket) live(key []byte) bool {
+	exp, ok := b.expiry(key)
+	return !ok || time.Now().Before(exp)
+}
+
+func (b *TxBucket) clearTTL(key []byte) error {
+	if b.tx == nil || !b.tx.Writable() {
+		return nil
+	}
+	ttl := b.tx.Bucket(ttlBucket)
+	if ttl == nil {
+		return nil
+	}
+
+	keys := ttl.Bucket(ttlKeysBucket)
+	id := ttlID(b.path, key)
+	v := keys.Get(id)
+	if v == nil {
+		return nil
+	}
+	if err := ttl.Bucket(ttlExpiryBucket).Delete(append(bytes.Clone(v), id...)); err != nil {
+		return err
+	}
+	return keys.Delete(id)
+}
+
+// dropTTL deletes TTL records of the bucket at path and its children
+func dropTTL(tx *bolt.Tx, path [][]byte, bucket *bolt.Bucket) error {
+	meta := tx.Bucket(ttlBucket)
+	if meta == nil || bucket == nil {
+		return nil
+	}
+	keys := meta.Bucket(ttlKeysBucket)
+	expiries := meta.Bucket(ttlExpiryBucket)
+
+	prefix := ttlID(path, nil)
+	var ids [][]byte
+	c := keys.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		ids = append(ids, bytes.Clone(k))
+	}
+	for _, id := range ids {
+		if err := expiries.Delete(append(bytes.Clone(keys.Get(id)), id...)); err != nil {
+			return err
+		}
+		if err := keys.Delete(id); err != nil {
+			return err
+		}
+	}
+
+	return bucket.ForEachBucket(func(name []byte) error {
+		child := append(path[:len(path):len(path)], name)
+		return dropTTL(tx, child, bucket.Bucket(name))
+	})
+}
+
+// PutWithTTL stores key-value which expires after ttl
+func (b *TxBucket) PutWithTTL(key, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("invalid ttl: %v", ttl)
+	}
+	if b.tx == nil {
+		return fmt.Errorf("ttl requires a bucket of BoltDB")
+	}
+	if err := b.Put(key, value); err != nil {
+		return err
+	}
+
+	meta, err := b.tx.CreateBucketIfNotExists(ttlBucket)
+	if err != nil {
+		return err
+	}
+	keys, err := meta.CreateBucketIfNotExists(ttlKeysBucket)
+	if err != nil {
+		return err
+	}
+	expiries, err := meta.CreateBucketIfNotExists(ttlExpiryBucket)
+	if err != nil {
+		return err
+	}
+
+	id := ttlID(b.path, key)
+	exp := Itob(uint64(time.Now().Add(ttl).UnixNano()))
+	if err := keys.Put(id, exp); err != nil {
+		return err
+	}
+	if b.db != nil {
+		b.tx.OnCommit(b.db.startJanitor)
+	}
+	return expiries.Put(append(exp, id...), nil)
+}
+
+// TTL returns remaining time to live, ok is false if key has no TTL
+func (b *TxBucket) TTL(key []byte) (time.Duration, bool) {
+	exp, ok := b.expiry(key)
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(exp), 0), true
+}
+
+// PutWithTTL stores key-value which expires after ttl
+//
+//	expired keys are treated as absent and deleted by the janitor
+func (b *Bucket) PutWithTTL(key, value []byte, ttl time.Duration) error {
+	return b.Update(func(tx *TxBucket) error {
+		return tx.PutWithTTL(key, value, ttl)
+	})
+}
+
+// TTL returns remaining time to live, ok is false if key has no TTL
+func (b *Bucket) TTL(key []byte) (time.Duration, bool, error) {
+	var ttl time.Duration
+	var ok bool
+	err := b.View(func(tx *TxBucket) error {
+		ttl, ok = tx.TTL(key)
+		return nil
+	})
+	return ttl, ok, err
+}
+
+// PurgeExpired deletes expired keys, returns the number of deleted keys
+func (b *BoltDB) PurgeExpired() (int, error) {
+	db, err := b.Connect()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		n, err := purgeExpired(db, time.Now(), JanitorBatchSize)
+		total += n
+		if err != nil || n < JanitorBatchSize {
+			return total, err
+		}
+	}
+}
+
+// purgeExpired deletes at most limit expired entries in one transaction
+func purgeExpired(db *bolt.DB, now time.Time, limit int) (int, error) {
+	count := 0
+	err := db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(ttlBucket)
+		if meta == nil {
+			return nil
+		}
+		keys := meta.Bucket(ttlKeysBucket)
+		expiries := meta.Bucket(ttlExpiryBucket)
+
+		deadline := Itob(uint64(now.UnixNano()))
+		var expired [][]byte
+		c := expiries.Cursor()
+		for k, _ := c.First(); k != nil && len(expired) < limit; k, _ = c.Next() {
+			if bytes.Compare(k[:8], deadline) > 0 {
+				break
+			}
+			expired = append(expired, bytes.Clone(k))
+		}
+
+		for _, entry := range expired {
+			if err := expiries.Delete(entry); err != nil {
+				return err
+			}
+			count++
+
+			exp, id := entry[:8], entry[8:]
+			// stale entry, the key was rewritten with another expiry
+			if !bytes.Equal(keys.Get(id), exp) {
+				continue
+			}
+			if err := keys.Delete(id); err != nil {
+				return err
+			}
+
+			path, key, err := parseTTLID(id)
+			if err != nil {
+				return err
+			}
+			if len(path) == 0 {
+				continue
+			}
+			bucket := tx.Bucket(path[0])
+			for _, name := range path[1:] {
+				if bucket == nil {
+					break
+				}
+				bucket = bucket.Bucket(name)
+			}
+			if bucket != nil && bucket.Get(key) != nil {
+				if err := bucket.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (b *BoltDB) startJanitor() {
+	b.janitorMu.Lock()
+	defer b.janitorMu.Unlock()
+	if b.janitorStop != nil || b.JanitorInterval <= 0 || b.db == nil || b.db.IsReadOnly() {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	b.janitorStop, b.janitorDone = stop, done
+
+	go func(db *bolt.DB, interval time.Duration) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for {
+					n, err := purgeExpired(db, time.Now(), JanitorBatchSize)
+					if err != nil || n < JanitorBatchSize {
+						break
+					}
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
+			}
+		}
+	}(b.db, b.JanitorInterval)
+}
+
+func (b *BoltDB) stopJanitor() {
+	b.janitorMu.Lock()
+	defer b.janitorMu.Unlock()
+	if b.janitorStop == nil {
+		return
+	}
+	close(b.janitorStop)
+	<-b.janitorDone
+	b.janitorStop, b.janitorDone = nil, nil
+}
@@ -0,0 +1,126 @@
+package boltdb
+
+// TypedBucket bucket with typed keys and values
+type TypedBucket[K, V any] struct {
+	bucket  *Bucket
+	keys    KeyEncoder[K]
+	codec   Codec
+	indexes []typedIndex[V]
+}
+
+// NewTypedBucket wrap a bucket with key encoder and value codec (default JSONCodec)
+//
+//	eg: users := boltdb.NewTypedBucket[string, User](db.Bucket("users"), boltdb.StringKey{}, boltdb.JSONCodec{})
+func NewTypedBucket[K, V any](bucket *Bucket, keys KeyEncoder[K], codec Codec) *TypedBucket[K, V] {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &TypedBucket[K, V]{
+		bucket: bucket,
+		keys:   keys,
+		codec:  codec,
+	}
+}
+
+// Bucket returns the underlying bucket
+func (t *TypedBucket[K, V]) Bucket() *Bucket {
+	return t.bucket
+}
+
+// Create creates bucket if not exists
+func (t *TypedBucket[K, V]) Create() error {
+	return t.bucket.Create()
+}
+
+func (t *TypedBucket[K, V]) decode(k, v []byte) (K, V, error) {
+	var value V
+	key, err := t.keys.DecodeKey(k)
+	if err != nil {
+		return key, value, err
+	}
+	err = t.codec.Unmarshal(v, &value)
+	return key, value, err
+}
+
+// Put stores typed key-value
+func (t *TypedBucket[K, V]) Put(key K, value V) error {
+	k, err := t.keys.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+
+	v, err := t.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if len(t.indexes) == 0 {
+		return t.bucket.Put(k, v)
+	}
+
+	return t.bucket.Update(func(tx *TxBucket) error {
+		if err := t.unindex(tx, k); err != nil {
+			return err
+		}
+		if err := tx.Put(k, v); err != nil {
+			return err
+		}
+		return t.reindex(tx, k, value)
+	})
+}
+
+// Get returns value, ok is false if key not exists
+func (t *TypedBucket[K, V]) Get(key K) (V, bool, error) {
+	var value V
+	k, err := t.keys.EncodeKey(key)
+	if err != nil {
+		return value, false, err
+	}
+
+	v, err := t.bucket.Get(k)
+	if err != nil || v == nil {
+		return value, false, err
+	}
+
+	if err := t.codec.Unmarshal(v, &value); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// Exists checks if key exists
+func (t *TypedBucket[K, V]) Exists(key K) (bool, error) {
+	k, err := t.keys.EncodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	return t.bucket.ExistsKey(k)
+}
+
+// Delete key
+func (t *TypedBucket[K, V]) Delete(key K) error {
+	k, err := t.keys.EncodeKey(key)
+	if err != nil {
+		return err
+	}
+	if len(t.indexes) == 0 {
+		return t.bucket.Delete(k)
+	}
+
+	return t.bucket.Update(func(tx *TxBucket) error {
+		if err := t.unindex(tx, k); err != nil {
+			return err
+		}
+		return tx.Delete(k)
+	})
+}
+
+// ForEach iterates all keys in key order
+func (t *TypedBucket[K, V]) ForEach(fn func(key K, value V) error) error {
+	return t.bucket.ForEach(nil, func(k, v []byte) error {
+		key, value, err := t.decode(k, v)
+		if err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
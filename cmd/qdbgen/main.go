@@ -0,0 +1,103 @@
+// qdbgen generates go model structs from an existing database schema
+//
+//	eg: qdbgen -driver sqlite -file app.db -pkg model -out model/model.go
+//	eg: qdbgen -driver postgresql -host 127.0.0.1 -port 5432 -user qmaru -password 123456 -dbname qmaru
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qmaru/qdb/mysql"
+	"github.com/qmaru/qdb/postgresql"
+	"github.com/qmaru/qdb/rdb"
+	"github.com/qmaru/qdb/sqlite"
+	"github.com/qmaru/qdb/sqlitep"
+)
+
+func main() {
+	driver := flag.String("driver", "sqlite", "database driver: sqlite | sqlitep | mysql | postgresql")
+	file := flag.String("file", "", "sqlite database file")
+	host := flag.String("host", "127.0.0.1", "database host")
+	port := flag.Int("port", 0, "database port (default 3306 for mysql, 5432 for postgresql)")
+	user := flag.String("user", "", "database username")
+	password := flag.String("password", "", "database password")
+	dbname := flag.String("dbname", "", "database name")
+	pkg := flag.String("pkg", "model", "package name of the generated file")
+	tables := flag.String("tables", "", "comma separated tables to generate, empty means all")
+	pointer := flag.Bool("pointer", false, "use pointer types for nullable columns")
+	out := flag.String("out", "", "output file, empty means stdout")
+	flag.Parse()
+
+	if err := run(*driver, *file, *host, *port, *user, *password, *dbname, *pkg, *tables, *pointer, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "qdbgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(driver, file, host string, port int, user, password, dbname, pkg, tables string, pointer bool, out string) error {
+	var inspector rdb.Inspector
+	var dialect rdb.Dialect
+
+	switch driver {
+	case "sqlite", "sqlitep":
+		if file == "" {
+			return fmt.Errorf("-file is required for %s", driver)
+		}
+		if driver == "sqlite" {
+			db := sqlite.New(file)
+			defer db.Close()
+			inspector = db
+		} else {
+			db := sqlitep.New(file)
+			defer db.Close()
+			inspector = db
+		}
+		dialect = rdb.DialectSqlite
+	case "mysql":
+		if port == 0 {
+			port = 3306
+		}
+		db := mysql.NewDefault(host, port, user, password, dbname)
+		defer db.Close()
+		inspector = db
+		dialect = rdb.DialectMySQL
+	case "postgresql", "postgres":
+		if port == 0 {
+			port = 5432
+		}
+		db := postgresql.NewDefault(host, port, user, password, dbname)
+		defer db.Close()
+		inspector = db
+		dialect = rdb.DialectPostgreSQL
+	default:
+		return fmt.Errorf("unknown driver: %s", driver)
+	}
+
+	schema, err := inspector.Inspect()
+	if err != nil {
+		return err
+	}
+
+	options := rdb.GenerateOptions{
+		Package:         pkg,
+		Dialect:         dialect,
+		NullablePointer: pointer,
+	}
+	if tables != "" {
+		options.Tables = strings.Split(tables, ",")
+	}
+
+	code, err := rdb.GenerateModels(schema, options)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,334 @@
+package internal
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/qmaru/qdb/rdb"
+)
+
+// SchemaBuilder Collect schema rows into rdb.Schema
+//
+//	rows of a multi-column index or foreign key must be added in column order
+type SchemaBuilder struct {
+	tables []*rdb.Table
+	byName map[string]*rdb.Table
+}
+
+func NewSchemaBuilder() *SchemaBuilder {
+	return &SchemaBuilder{
+		byName: make(map[string]*rdb.Table),
+	}
+}
+
+func (b *SchemaBuilder) table(name string) *rdb.Table {
+	t, ok := b.byName[name]
+	if !ok {
+		t = &rdb.Table{Name: name}
+		b.tables = append(b.tables, t)
+		b.byName[name] = t
+	}
+	return t
+}
+
+func (b *SchemaBuilder) AddTable(name, comment string) {
+	b.table(name).Comment = comment
+}
+
+func (b *SchemaBuilder) AddColumn(table string, column rdb.Column) {
+	t := b.table(table)
+	t.Columns = append(t.Columns, column)
+}
+
+// AddIndex add an index or append its columns to the previous one with the same name
+func (b *SchemaBuilder) AddIndex(table string, index rdb.Index) {
+	t := b.table(table)
+	if n := len(t.Indexes); n > 0 && t.Indexes[n-1].Name == index.Name {
+		t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, index.Columns...)
+		return
+	}
+	t.Indexes = append(t.Indexes, index)
+}
+
+// AddForeignKey add a foreign key or append its columns to the previous one with the same name
+func (b *SchemaBuilder) AddForeignKey(table string, fk rdb.ForeignKey) {
+	t := b.table(table)
+	if n := len(t.ForeignKeys); n > 0 && fk.Name != "" && t.ForeignKeys[n-1].Name == fk.Name {
+		last := &t.ForeignKeys[n-1]
+		last.Columns = append(last.Columns, fk.Columns...)
+		last.RefColumns = append(last.RefColumns, fk.RefColumns...)
+		return
+	}
+	t.ForeignKeys = append(t.ForeignKeys, fk)
+}
+
+// Schema return the collected schema, marking primary key columns from primary indexes
+func (b *SchemaBuilder) Schema() *rdb.Schema {
+	schema := &rdb.Schema{Tables: make([]rdb.Table, 0, len(b.tables))}
+	for _, t := range b.tables {
+		for _, index := range t.Indexes {
+			if !index.Primary {
+				continue
+			}
+			for _, name := range index.Columns {
+				if c := t.Column(name); c != nil {
+					c.PrimaryKey = true
+				}
+			}
+		}
+		schema.Tables = append(schema.Tables, *t)
+	}
+	return schema
+}
+
+// NullStringPtr convert sql.NullString to *string
+func NullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	v := s.String
+	return &v
+}
+
+func (s *SqliteBase) Inspect() (*rdb.Schema, error) {
+	return Inspect(s)
+}
+
+func sqliteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Inspect read sqlite schema from sqlite_master and PRAGMA
+func Inspect(c Connector) (*rdb.Schema, error) {
+	db, err := c.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := sqliteTables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := NewSchemaBuilder()
+	for _, table := range tables {
+		builder.AddTable(table, "")
+		if err := sqliteColumns(db, builder, table); err != nil {
+			return nil, err
+		}
+		if err := sqliteIndexes(db, builder, table); err != nil {
+			return nil, err
+		}
+		if err := sqliteForeignKeys(db, builder, table); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Schema(), nil
+}
+
+func sqliteTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+func sqliteColumns(db *sql.DB, builder *SchemaBuilder, table string) error {
+	pkNotNull, err := sqlitePrimaryKeyNotNull(db, table)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", sqliteIdent(table)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var columns []rdb.Column
+	pkCount := 0
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			ctype   string
+			notNull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if pk > 0 {
+			pkCount++
+		}
+		columns = append(columns, rdb.Column{
+			Name:       name,
+			Type:       ctype,
+			Nullable:   notNull == 0 && !(pk > 0 && pkNotNull),
+			Default:    NullStringPtr(dflt),
+			PrimaryKey: pk > 0,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		// INTEGER PRIMARY KEY is an alias of rowid, never NULL
+		if column.PrimaryKey && pkCount == 1 && strings.EqualFold(column.Type, "INTEGER") {
+			column.Nullable = false
+		}
+		builder.AddColumn(table, column)
+	}
+	return nil
+}
+
+// sqlitePrimaryKeyNotNull primary key columns of STRICT and WITHOUT ROWID tables are NOT NULL
+func sqlitePrimaryKeyNotNull(db *sql.DB, table string) (bool, error) {
+	var (
+		schema string
+		name   string
+		ttype  string
+		ncol   int
+		wr     int
+		strict int
+	)
+	err := db.QueryRow(fmt.Sprintf("PRAGMA table_list(%s)", sqliteIdent(table))).Scan(&schema, &name, &ttype, &ncol, &wr, &strict)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return wr != 0 || strict != 0, nil
+}
+
+func sqliteIndexes(db *sql.DB, builder *SchemaBuilder, table string) error {
+	type indexInfo struct {
+		name    string
+		unique  bool
+		primary bool
+	}
+
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", sqliteIdent(table)))
+	if err != nil {
+		return err
+	}
+
+	var indexes []indexInfo
+	for rows.Next() {
+		var (
+			seq     int
+			name    string
+			unique  int
+			origin  string
+			partial int
+		)
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return err
+		}
+		indexes = append(indexes, indexInfo{name: name, unique: unique == 1, primary: origin == "pk"})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := len(indexes) - 1; i >= 0; i-- {
+		index := indexes[i]
+		columns, err := sqliteIndexColumns(db, index.name)
+		if err != nil {
+			return err
+		}
+		builder.AddIndex(table, rdb.Index{
+			Name:    index.name,
+			Columns: columns,
+			Unique:  index.unique,
+			Primary: index.primary,
+		})
+	}
+	return nil
+}
+
+func sqliteIndexColumns(db *sql.DB, index string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", sqliteIdent(index)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			seqno int
+			cid   int
+			name  sql.NullString
+		)
+		if err := rows.Scan(&seqno, &cid, &name); err != nil {
+			return nil, err
+		}
+		if name.Valid {
+			columns = append(columns, name.String)
+		}
+	}
+	return columns, rows.Err()
+}
+
+func sqliteForeignKeys(db *sql.DB, builder *SchemaBuilder, table string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA foreign_key_list(%s)", sqliteIdent(table)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var fks []rdb.ForeignKey
+	lastID := -1
+	for rows.Next() {
+		var (
+			id       int
+			seq      int
+			refTable string
+			from     string
+			to       sql.NullString
+			onUpdate string
+			onDelete string
+			match    string
+		)
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return err
+		}
+		if id != lastID {
+			fks = append(fks, rdb.ForeignKey{
+				RefTable: refTable,
+				OnUpdate: onUpdate,
+				OnDelete: onDelete,
+			})
+			lastID = id
+		}
+		fk := &fks[len(fks)-1]
+		fk.Columns = append(fk.Columns, from)
+		fk.RefColumns = append(fk.RefColumns, to.String)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, fk := range fks {
+		builder.AddForeignKey(table, fk)
+	}
+	return nil
+}
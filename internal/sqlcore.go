@@ -163,6 +163,11 @@ func CreateTable(c Connector, tables []any) error {
 		return err
 	}
 
+	tables, err = rdb.SortTables(tables)
+	if err != nil {
+		return err
+	}
+
 	for _, table := range tables {
 		var buffer bytes.Buffer
 		rType := reflect.TypeOf(table)
@@ -176,9 +181,20 @@ func CreateTable(c Connector, tables []any) error {
 		if len(b) == 0 {
 			continue
 		}
+
+		refs, err := rdb.DBForeignKeys(rType)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			buffer.WriteString(ref.String())
+			buffer.WriteString(",")
+		}
+
+		b = buffer.Bytes()
 		rFiled := b[0 : len(b)-1]
 		sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", rName, rFiled)
-		_, err := sdb.Exec(sql)
+		_, err = sdb.Exec(sql)
 		if err != nil {
 			return err
 		}
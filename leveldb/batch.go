@@ -0,0 +1,101 @@
+package leveldb
+
+import (
+	"bytes"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// Batch atomic write batch, Put and Delete clear the TTL of keys
+//
+//	Write fails if an internal key was added
+type Batch struct {
+	ldb   *LevelDB
+	batch *leveldb.Batch
+	keys  [][]byte
+	err   error
+}
+
+// NewBatch create an empty batch
+func (ldb *LevelDB) NewBatch() *Batch {
+	return &Batch{ldb: ldb, batch: new(leveldb.Batch)}
+}
+
+// WriteBatch run fn with a new batch, write it if fn returns nil, discard otherwise
+//
+//	eg: ldb.WriteBatch(func(b *leveldb.Batch) error { b.Put(k, v); return nil })
+func (ldb *LevelDB) WriteBatch(fn func(b *Batch) error) error {
+	b := ldb.NewBatch()
+	if err := fn(b); err != nil {
+		return err
+	}
+	return b.Write(ldb.Sync)
+}
+
+func (b *Batch) Put(key, value []byte) {
+	if isInternalKey(key) {
+		b.err = errReservedKey
+		return
+	}
+	b.batch.Put(key, value)
+	b.keys = append(b.keys, bytes.Clone(key))
+}
+
+func (b *Batch) Delete(key []byte) {
+	if isInternalKey(key) {
+		b.err = errReservedKey
+		return
+	}
+	b.batch.Delete(key)
+	b.keys = append(b.keys, bytes.Clone(key))
+}
+
+// Len returns the number of records
+func (b *Batch) Len() int {
+	return b.batch.Len()
+}
+
+// Reset reset the batch for reuse
+func (b *Batch) Reset() {
+	b.batch.Reset()
+	b.keys = nil
+	b.err = nil
+}
+
+// Write write the batch atomically, sync flushes it to disk before returning
+func (b *Batch) Write(sync bool) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	db, err := b.ldb.Connect()
+	if err != nil {
+		return err
+	}
+
+	wo := &opt.WriteOptions{Sync: sync}
+	if !b.ldb.hasTTL.Load() {
+		return db.Write(b.batch, wo)
+	}
+
+	b.ldb.ttlMu.Lock()
+	defer b.ldb.ttlMu.Unlock()
+
+	full := new(leveldb.Batch)
+	for _, key := range b.keys {
+		exp, err := db.Get(ttlKey(key), nil)
+		switch err {
+		case nil:
+			full.Delete(ttlKey(key))
+			full.Delete(ttlExpiryKey(exp, key))
+		case leveldb.ErrNotFound:
+		default:
+			return err
+		}
+	}
+	if err := b.batch.Replay(full); err != nil {
+		return err
+	}
+	return db.Write(full, wo)
+}
@@ -0,0 +1,193 @@
+package leveldb
+
+import (
+	"bytes"
+	"iter"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Max keys deleted in one batch by DeletePrefix and DeleteRange
+const DeleteBatchSize = 1000
+
+// RangeOptions range iteration options
+//
+//	Start/End: key bounds [Start, End), nil means unbounded
+//	Prefix: only keys with prefix
+//	Reverse: iterate from the end of the range
+//	Limit: max items, 0 means no limit
+type RangeOptions struct {
+	Start   []byte
+	End     []byte
+	Prefix  []byte
+	Reverse bool
+	Limit   int
+}
+
+// source is implemented by leveldb.DB, leveldb.Snapshot and leveldb.Transaction
+type source interface {
+	reader
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+// Iterator lazy range iterator, the underlying iterator is released when the loop ends
+//
+//	eg: it := ldb.Range(leveldb.RangeOptions{Prefix: []byte("user:")})
+//	eg: for k, v := range it.All() {}
+//	eg: err := it.Err()
+type Iterator struct {
+	ldb  *LevelDB
+	src  source
+	opts RangeOptions
+	err  error
+}
+
+// slice returns the intersection of bounds and prefix
+func (o RangeOptions) slice() *util.Range {
+	r := &util.Range{Start: o.Start, Limit: o.End}
+	if o.Prefix != nil {
+		p := util.BytesPrefix(o.Prefix)
+		if r.Start == nil || bytes.Compare(p.Start, r.Start) > 0 {
+			r.Start = p.Start
+		}
+		if p.Limit != nil && (r.Limit == nil || bytes.Compare(p.Limit, r.Limit) < 0) {
+			r.Limit = p.Limit
+		}
+	}
+	return r
+}
+
+// scan iterates keys in range, internal keys are skipped, fn returns false to stop
+func (ldb *LevelDB) scan(src source, opts RangeOptions, skipExpired bool, fn func(k, v []byte) bool) error {
+	it := src.NewIterator(opts.slice(), nil)
+	defer it.Release()
+
+	next := it.Next
+	ok := it.First()
+	if opts.Reverse {
+		next = it.Prev
+		ok = it.Last()
+	}
+
+	count := 0
+	for ; ok; ok = next() {
+		if opts.Limit > 0 && count >= opts.Limit {
+			break
+		}
+		k := it.Key()
+		if isInternalKey(k) || skipExpired && !ldb.live(src, k) {
+			continue
+		}
+		count++
+		if !fn(k, it.Value()) {
+			break
+		}
+	}
+	return it.Error()
+}
+
+// All returns key-values of the range, iteration stops on error
+func (it *Iterator) All() iter.Seq2[[]byte, []byte] {
+	return func(yield func(k, v []byte) bool) {
+		if it.err != nil {
+			return
+		}
+		it.err = it.ldb.scan(it.src, it.opts, true, func(k, v []byte) bool {
+			return yield(bytes.Clone(k), bytes.Clone(v))
+		})
+	}
+}
+
+// Err returns the error of Range or the last iteration
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Range iterate keys in range, keys and values are copies
+func (ldb *LevelDB) Range(opts RangeOptions) *Iterator {
+	db, err := ldb.Connect()
+	if err != nil {
+		return &Iterator{ldb: ldb, err: err}
+	}
+	return &Iterator{ldb: ldb, src: db, opts: opts}
+}
+
+// Prefix iterate keys with prefix
+func (ldb *LevelDB) Prefix(prefix []byte) *Iterator {
+	return ldb.Range(RangeOptions{Prefix: prefix})
+}
+
+// Range iterate keys in range of the snapshot
+func (s *Snapshot) Range(opts RangeOptions) *Iterator {
+	return &Iterator{ldb: s.ldb, src: s.snap, opts: opts}
+}
+
+// DeletePrefix delete keys with prefix, returns the number of deleted keys
+func (ldb *LevelDB) DeletePrefix(prefix []byte) (int, error) {
+	return ldb.deleteRange(RangeOptions{Prefix: prefix})
+}
+
+// DeleteRange delete keys in [start, end), returns the number of deleted keys
+func (ldb *LevelDB) DeleteRange(start, end []byte) (int, error) {
+	return ldb.deleteRange(RangeOptions{Start: start, End: end})
+}
+
+func (ldb *LevelDB) deleteRange(opts RangeOptions) (int, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	opts.Limit = DeleteBatchSize
+	for {
+		var keys [][]byte
+		err := ldb.scan(db, opts, false, func(k, _ []byte) bool {
+			keys = append(keys, bytes.Clone(k))
+			return true
+		})
+		if err != nil {
+			return total, err
+		}
+		if len(keys) == 0 {
+			return total, nil
+		}
+
+		if err := ldb.deleteKeys(db, keys); err != nil {
+			return total, err
+		}
+		total += len(keys)
+		if len(keys) < DeleteBatchSize {
+			return total, nil
+		}
+		// continue after the last deleted key
+		opts.Start = append(keys[len(keys)-1], 0)
+	}
+}
+
+// deleteKeys delete keys and their TTL in one batch
+func (ldb *LevelDB) deleteKeys(db *leveldb.DB, keys [][]byte) error {
+	ldb.ttlMu.Lock()
+	defer ldb.ttlMu.Unlock()
+
+	batch := new(leveldb.Batch)
+	for _, key := range keys {
+		batch.Delete(key)
+		if !ldb.hasTTL.Load() {
+			continue
+		}
+		exp, err := db.Get(ttlKey(key), nil)
+		switch err {
+		case nil:
+			batch.Delete(ttlKey(key))
+			batch.Delete(ttlExpiryKey(exp, key))
+		case leveldb.ErrNotFound:
+		default:
+			return err
+		}
+	}
+	return db.Write(batch, ldb.writeOptions())
+}
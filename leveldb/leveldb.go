@@ -2,34 +2,108 @@ package leveldb
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
+// Options goleveldb options, eg: BlockCacheCapacity, WriteBuffer, Compression, Filter, ReadOnly
+type Options = opt.Options
+
 type LevelDB struct {
-	FileName string
-	once     sync.Once
-	db       *leveldb.DB
-	err      error
+	FileName        string
+	JanitorInterval time.Duration
+	Options         *Options
+	Sync            bool
+	memory          bool
+	once            sync.Once
+	db              *leveldb.DB
+	err             error
+	hasTTL          atomic.Bool
+	ttlMu           sync.Mutex
+	janitorMu       sync.Mutex
+	janitorStop     chan struct{}
+	janitorDone     chan struct{}
+}
+
+// NewLevelDBOptions returns goleveldb defaults with a 10 bits bloom filter
+func NewLevelDBOptions() Options {
+	return Options{
+		Filter: filter.NewBloomFilter(10),
+	}
 }
 
 func New(filename string) *LevelDB {
 	return &LevelDB{
-		FileName: filename,
+		FileName:        filename,
+		JanitorInterval: DefaultJanitorInterval,
+	}
+}
+
+// NewWithOptions nil options means goleveldb defaults
+func NewWithOptions(filename string, options *Options) *LevelDB {
+	return &LevelDB{
+		FileName:        filename,
+		JanitorInterval: DefaultJanitorInterval,
+		Options:         options,
+	}
+}
+
+// NewMemory create an in-memory database, data is lost on Close
+func NewMemory() *LevelDB {
+	return &LevelDB{
+		JanitorInterval: DefaultJanitorInterval,
+		memory:          true,
 	}
 }
 
+// SetSync sets whether writes are flushed to disk before returning
+func (ldb *LevelDB) SetSync(sync bool) *LevelDB {
+	ldb.Sync = sync
+	return ldb
+}
+
+// SetJanitorInterval sets how often expired keys are purged, 0 disables the janitor
+func (ldb *LevelDB) SetJanitorInterval(interval time.Duration) *LevelDB {
+	ldb.JanitorInterval = interval
+	return ldb
+}
+
 // Connect create database
 func (ldb *LevelDB) Connect() (*leveldb.DB, error) {
 	ldb.once.Do(func() {
-		ldb.db, ldb.err = leveldb.OpenFile(ldb.FileName, nil)
+		if ldb.memory {
+			ldb.db, ldb.err = leveldb.Open(storage.NewMemStorage(), ldb.Options)
+		} else {
+			ldb.db, ldb.err = leveldb.OpenFile(ldb.FileName, ldb.Options)
+		}
+		if ldb.err == nil && detectTTL(ldb.db) {
+			ldb.hasTTL.Store(true)
+			ldb.startJanitor()
+		}
 	})
 	return ldb.db, ldb.err
 }
 
+func (ldb *LevelDB) readOnly() bool {
+	return ldb.Options != nil && ldb.Options.ReadOnly
+}
+
+func (ldb *LevelDB) writeOptions() *opt.WriteOptions {
+	if !ldb.Sync {
+		return nil
+	}
+	return &opt.WriteOptions{Sync: true}
+}
+
 func (ldb *LevelDB) Close() error {
+	ldb.stopJanitor()
 	if ldb.db == nil {
 		return nil
 	}
@@ -38,20 +112,34 @@ func (ldb *LevelDB) Close() error {
 
 // Set create key-value
 func (ldb *LevelDB) Set(key, value []byte) error {
+	if isInternalKey(key) {
+		return errReservedKey
+	}
+
 	db, err := ldb.Connect()
 	if err != nil {
 		return err
 	}
 
-	err = db.Put(key, value, nil)
+	if ldb.hasTTL.Load() {
+		return ldb.writeTTL(db, key, func(batch *leveldb.Batch) {
+			batch.Put(key, value)
+		})
+	}
+
+	err = db.Put(key, value, ldb.writeOptions())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Get get value of key
+// Get get value of key, expired key returns leveldb.ErrNotFound
 func (ldb *LevelDB) Get(key []byte) ([]byte, error) {
+	if isInternalKey(key) {
+		return nil, leveldb.ErrNotFound
+	}
+
 	db, err := ldb.Connect()
 	if err != nil {
 		return nil, err
@@ -61,10 +149,15 @@ func (ldb *LevelDB) Get(key []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if !ldb.live(db, key) {
+		return nil, leveldb.ErrNotFound
+	}
 	return value, nil
 }
 
 // GetBatch get some key with prefix
+//
+// Deprecated: use Prefix or Range, which keep key order and stream results
 func (ldb *LevelDB) GetBatch(keyPrefix string) (map[string]any, error) {
 	db, err := ldb.Connect()
 	if err != nil {
@@ -76,6 +169,9 @@ func (ldb *LevelDB) GetBatch(keyPrefix string) (map[string]any, error) {
 
 	data := make(map[string]any)
 	for iter.Next() {
+		if isInternalKey(iter.Key()) || !ldb.live(db, iter.Key()) {
+			continue
+		}
 		k := string(iter.Key())
 		v := iter.Value()
 		vcopy := make([]byte, len(v))
@@ -91,12 +187,22 @@ func (ldb *LevelDB) GetBatch(keyPrefix string) (map[string]any, error) {
 
 // Del delete a key
 func (ldb *LevelDB) Del(key []byte) error {
+	if isInternalKey(key) {
+		return errReservedKey
+	}
+
 	db, err := ldb.Connect()
 	if err != nil {
 		return err
 	}
 
-	err = db.Delete(key, nil)
+	if ldb.hasTTL.Load() {
+		return ldb.writeTTL(db, key, func(batch *leveldb.Batch) {
+			batch.Delete(key)
+		})
+	}
+
+	err = db.Delete(key, ldb.writeOptions())
 	if err != nil {
 		return err
 	}
@@ -105,6 +211,10 @@ func (ldb *LevelDB) Del(key []byte) error {
 
 // Check check a key
 func (ldb *LevelDB) Check(key []byte) (bool, error) {
+	if isInternalKey(key) {
+		return false, nil
+	}
+
 	db, err := ldb.Connect()
 	if err != nil {
 		return false, err
@@ -114,10 +224,12 @@ func (ldb *LevelDB) Check(key []byte) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return ok, nil
+	return ok && ldb.live(db, key), nil
 }
 
 // Batch create a batch instance
+//
+// Deprecated: use NewBatch or WriteBatch, which write through LevelDB
 func (ldb *LevelDB) Batch() (*leveldb.DB, *leveldb.Batch, error) {
 	db, err := ldb.Connect()
 	if err != nil {
@@ -128,6 +240,8 @@ func (ldb *LevelDB) Batch() (*leveldb.DB, *leveldb.Batch, error) {
 }
 
 // Iter create a iterator
+//
+// Deprecated: use Range, which releases the iterator when the loop ends
 func (ldb *LevelDB) Iter() (*leveldb.DB, iterator.Iterator, error) {
 	db, err := ldb.Connect()
 	if err != nil {
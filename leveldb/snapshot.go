@@ -0,0 +1,60 @@
+package leveldb
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Snapshot frozen view of the database for consistent multi-key reads
+type Snapshot struct {
+	ldb  *LevelDB
+	snap *leveldb.Snapshot
+}
+
+// Snapshot create a snapshot, must be released after use
+//
+//	eg: snap, err := ldb.Snapshot()
+//	eg: defer snap.Release()
+func (ldb *LevelDB) Snapshot() (*Snapshot, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{ldb: ldb, snap: snap}, nil
+}
+
+// Get get value of key, expired key returns leveldb.ErrNotFound
+func (s *Snapshot) Get(key []byte) ([]byte, error) {
+	if isInternalKey(key) {
+		return nil, leveldb.ErrNotFound
+	}
+	value, err := s.snap.Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !s.ldb.live(s.snap, key) {
+		return nil, leveldb.ErrNotFound
+	}
+	return value, nil
+}
+
+// Has check a key
+func (s *Snapshot) Has(key []byte) (bool, error) {
+	if isInternalKey(key) {
+		return false, nil
+	}
+	ok, err := s.snap.Has(key, nil)
+	if err != nil {
+		return false, err
+	}
+	return ok && s.ldb.live(s.snap, key), nil
+}
+
+// Release release the snapshot
+func (s *Snapshot) Release() {
+	s.snap.Release()
+}
@@ -0,0 +1,153 @@
+package leveldb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+type Range = util.Range
+
+// Stats database statistics
+type Stats struct {
+	Levels             []LevelStats
+	BlockCacheSize     int
+	OpenedTables       int
+	AliveSnapshots     int32
+	AliveIterators     int32
+	IORead             uint64
+	IOWrite            uint64
+	WriteDelayCount    int32
+	WriteDelayDuration time.Duration
+	WritePaused        bool
+	// Raw value of leveldb.stats
+	Raw string
+}
+
+// LevelStats compaction statistics of a level, sizes are in bytes
+type LevelStats struct {
+	Level    int
+	Tables   int
+	Size     int64
+	Duration time.Duration
+	Read     int64
+	Write    int64
+}
+
+// CompactRange compact keys in [start, limit), nil means unbounded
+func (ldb *LevelDB) CompactRange(start, limit []byte) error {
+	db, err := ldb.Connect()
+	if err != nil {
+		return err
+	}
+	return db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
+// SizeOf returns approximate disk usage of each range
+//
+//	eg: sizes, err := ldb.SizeOf(leveldb.Range{Start: []byte("a"), Limit: []byte("z")})
+func (ldb *LevelDB) SizeOf(ranges ...Range) ([]int64, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := db.SizeOf(ranges)
+	if err != nil {
+		return nil, err
+	}
+	return []int64(sizes), nil
+}
+
+// Property returns a goleveldb property, eg: leveldb.stats, leveldb.num-files-at-level0
+func (ldb *LevelDB) Property(name string) (string, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return "", err
+	}
+	return db.GetProperty(name)
+}
+
+// Stats returns structured statistics from goleveldb properties
+func (ldb *LevelDB) Stats() (*Stats, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	var dbStats leveldb.DBStats
+	if err := db.Stats(&dbStats); err != nil {
+		return nil, err
+	}
+	stats := &Stats{
+		BlockCacheSize:     dbStats.BlockCacheSize,
+		OpenedTables:       dbStats.OpenedTablesCount,
+		AliveSnapshots:     dbStats.AliveSnapshots,
+		AliveIterators:     dbStats.AliveIterators,
+		IORead:             dbStats.IORead,
+		IOWrite:            dbStats.IOWrite,
+		WriteDelayCount:    dbStats.WriteDelayCount,
+		WriteDelayDuration: dbStats.WriteDelayDuration,
+		WritePaused:        dbStats.WritePaused,
+	}
+
+	stats.Raw, err = db.GetProperty("leveldb.stats")
+	if err != nil {
+		return nil, err
+	}
+	stats.Levels, err = parseLevelStats(stats.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats.Levels {
+		files, err := db.GetProperty(fmt.Sprintf("leveldb.num-files-at-level%d", stats.Levels[i].Level))
+		if err != nil {
+			return nil, err
+		}
+		if stats.Levels[i].Tables, err = strconv.Atoi(files); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// parseLevelStats parses the table of leveldb.stats, levels without tables and compactions are omitted
+//
+//	eg: "   0   |          1 |       0.00012 |       0.00000 |       0.00000 |       0.00012"
+func parseLevelStats(raw string) ([]LevelStats, error) {
+	const mb = 1048576.0
+
+	var levels []LevelStats
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) != 6 {
+			continue
+		}
+		level, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			// header line
+			continue
+		}
+
+		var values [5]float64
+		for i, field := range fields[1:] {
+			if values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+				return nil, fmt.Errorf("invalid leveldb.stats line %q: %v", line, err)
+			}
+		}
+		levels = append(levels, LevelStats{
+			Level:    level,
+			Tables:   int(values[0]),
+			Size:     int64(values[1] * mb),
+			Duration: time.Duration(values[2] * float64(time.Second)),
+			Read:     int64(values[3] * mb),
+			Write:    int64(values[4] * mb),
+		})
+	}
+	return levels, nil
+}
@@ -0,0 +1,124 @@
+package leveldb
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Transaction atomic read-write transaction, other writes are blocked until Commit or Discard
+type Transaction struct {
+	ldb *LevelDB
+	tr  *leveldb.Transaction
+}
+
+// OpenTransaction open a transaction, must be committed or discarded
+func (ldb *LevelDB) OpenTransaction() (*Transaction, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := db.OpenTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{ldb: ldb, tr: tr}, nil
+}
+
+// Transaction run fn in a transaction, commit if fn returns nil, discard otherwise
+//
+//	fn must not call write methods of LevelDB, they block until the transaction ends
+func (ldb *LevelDB) Transaction(fn func(tr *Transaction) error) error {
+	tr, err := ldb.OpenTransaction()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tr); err != nil {
+		tr.Discard()
+		return err
+	}
+
+	if err := tr.Commit(); err != nil {
+		tr.Discard()
+		return err
+	}
+	return nil
+}
+
+// Get get value of key, expired key returns leveldb.ErrNotFound
+func (t *Transaction) Get(key []byte) ([]byte, error) {
+	if isInternalKey(key) {
+		return nil, leveldb.ErrNotFound
+	}
+	value, err := t.tr.Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !t.ldb.live(t.tr, key) {
+		return nil, leveldb.ErrNotFound
+	}
+	return value, nil
+}
+
+// Has check a key
+func (t *Transaction) Has(key []byte) (bool, error) {
+	if isInternalKey(key) {
+		return false, nil
+	}
+	ok, err := t.tr.Has(key, nil)
+	if err != nil {
+		return false, err
+	}
+	return ok && t.ldb.live(t.tr, key), nil
+}
+
+// Put create key-value and clear its TTL
+func (t *Transaction) Put(key, value []byte) error {
+	if isInternalKey(key) {
+		return errReservedKey
+	}
+	if err := t.clearTTL(key); err != nil {
+		return err
+	}
+	return t.tr.Put(key, value, nil)
+}
+
+// Delete delete a key
+func (t *Transaction) Delete(key []byte) error {
+	if isInternalKey(key) {
+		return errReservedKey
+	}
+	if err := t.clearTTL(key); err != nil {
+		return err
+	}
+	return t.tr.Delete(key, nil)
+}
+
+func (t *Transaction) clearTTL(key []byte) error {
+	if !t.ldb.hasTTL.Load() {
+		return nil
+	}
+
+	exp, err := t.tr.Get(ttlKey(key), nil)
+	switch err {
+	case nil:
+	case leveldb.ErrNotFound:
+		return nil
+	default:
+		return err
+	}
+	if err := t.tr.Delete(ttlKey(key), nil); err != nil {
+		return err
+	}
+	return t.tr.Delete(ttlExpiryKey(exp, key), nil)
+}
+
+// Commit commit the transaction
+func (t *Transaction) Commit() error {
+	return t.tr.Commit()
+}
+
+// Discard discard the transaction
+func (t *Transaction) Discard() {
+	t.tr.Discard()
+}
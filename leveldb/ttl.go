@@ -0,0 +1,240 @@
+package leveldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// reader is implemented by leveldb.DB, leveldb.Snapshot and leveldb.Transaction
+type reader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+}
+
+// Default interval of the expiration janitor (1 minute)
+const DefaultJanitorInterval = time.Minute
+
+// Max expired keys deleted in one batch
+const JanitorBatchSize = 1000
+
+// internal keys are hidden from reads
+const internalKeyPrefix = "\xff\xff__qdb_"
+
+var (
+	// ttlKeyPrefix + key => expiry
+	ttlKeyPrefix = []byte(internalKeyPrefix + "ttl_k:")
+	// ttlExpiryPrefix + expiry + key => nil
+	ttlExpiryPrefix = []byte(internalKeyPrefix + "ttl_e:")
+)
+
+// errReservedKey returned by writes of internal keys
+var errReservedKey = fmt.Errorf("key prefix %q is reserved", internalKeyPrefix)
+
+func isInternalKey(key []byte) bool {
+	return bytes.HasPrefix(key, []byte(internalKeyPrefix))
+}
+
+func ttlKey(key []byte) []byte {
+	return append(bytes.Clone(ttlKeyPrefix), key...)
+}
+
+func ttlExpiryKey(exp, key []byte) []byte {
+	k := append(bytes.Clone(ttlExpiryPrefix), exp...)
+	return append(k, key...)
+}
+
+func encodeExpiry(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
+
+func detectTTL(db *leveldb.DB) bool {
+	iter := db.NewIterator(util.BytesPrefix(ttlKeyPrefix), nil)
+	defer iter.Release()
+	return iter.First()
+}
+
+func (ldb *LevelDB) expiry(db reader, key []byte) (time.Time, bool) {
+	if !ldb.hasTTL.Load() {
+		return time.Time{}, false
+	}
+	v, err := db.Get(ttlKey(key), nil)
+	if err != nil || len(v) != 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v))), true
+}
+
+// live key has no TTL or is not expired yet
+func (ldb *LevelDB) live(db reader, key []byte) bool {
+	exp, ok := ldb.expiry(db, key)
+	return !ok || time.Now().Before(exp)
+}
+
+// writeTTL clears the TTL of key and applies fn in one batch
+func (ldb *LevelDB) writeTTL(db *leveldb.DB, key []byte, fn func(batch *leveldb.Batch)) error {
+	ldb.ttlMu.Lock()
+	defer ldb.ttlMu.Unlock()
+
+	batch := new(leveldb.Batch)
+	exp, err := db.Get(ttlKey(key), nil)
+	switch err {
+	case nil:
+		batch.Delete(ttlKey(key))
+		batch.Delete(ttlExpiryKey(exp, key))
+	case leveldb.ErrNotFound:
+	default:
+		return err
+	}
+	fn(batch)
+	return db.Write(batch, ldb.writeOptions())
+}
+
+// SetWithTTL create key-value which expires after ttl
+//
+//	expired keys are treated as absent and deleted by the janitor
+func (ldb *LevelDB) SetWithTTL(key, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("invalid ttl: %v", ttl)
+	}
+	if isInternalKey(key) {
+		return errReservedKey
+	}
+
+	db, err := ldb.Connect()
+	if err != nil {
+		return err
+	}
+
+	ldb.hasTTL.Store(true)
+	exp := encodeExpiry(time.Now().Add(ttl))
+	err = ldb.writeTTL(db, key, func(batch *leveldb.Batch) {
+		batch.Put(key, value)
+		batch.Put(ttlKey(key), exp)
+		batch.Put(ttlExpiryKey(exp, key), nil)
+	})
+	if err != nil {
+		return err
+	}
+	ldb.startJanitor()
+	return nil
+}
+
+// TTL returns remaining time to live, ok is false if key has no TTL
+func (ldb *LevelDB) TTL(key []byte) (time.Duration, bool, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return 0, false, err
+	}
+
+	exp, ok := ldb.expiry(db, key)
+	if !ok {
+		return 0, false, nil
+	}
+	return max(time.Until(exp), 0), true, nil
+}
+
+// PurgeExpired deletes expired keys, returns the number of deleted keys
+func (ldb *LevelDB) PurgeExpired() (int, error) {
+	db, err := ldb.Connect()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		n, err := ldb.purgeExpired(db, time.Now(), JanitorBatchSize)
+		total += n
+		if err != nil || n < JanitorBatchSize {
+			return total, err
+		}
+	}
+}
+
+// purgeExpired deletes at most limit expired entries in one batch
+func (ldb *LevelDB) purgeExpired(db *leveldb.DB, now time.Time, limit int) (int, error) {
+	ldb.ttlMu.Lock()
+	defer ldb.ttlMu.Unlock()
+
+	limitKey := ttlExpiryKey(encodeExpiry(now.Add(time.Nanosecond)), nil)
+	iter := db.NewIterator(&util.Range{Start: ttlExpiryPrefix, Limit: limitKey}, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	count := 0
+	for count < limit && iter.Next() {
+		entry := iter.Key()
+		batch.Delete(bytes.Clone(entry))
+		count++
+
+		exp := entry[len(ttlExpiryPrefix) : len(ttlExpiryPrefix)+8]
+		key := bytes.Clone(entry[len(ttlExpiryPrefix)+8:])
+		current, err := db.Get(ttlKey(key), nil)
+		// stale entry, the key was rewritten with another expiry
+		if err != nil || !bytes.Equal(current, exp) {
+			continue
+		}
+		batch.Delete(ttlKey(key))
+		batch.Delete(key)
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return count, db.Write(batch, ldb.writeOptions())
+}
+
+func (ldb *LevelDB) startJanitor() {
+	ldb.janitorMu.Lock()
+	defer ldb.janitorMu.Unlock()
+	if ldb.janitorStop != nil || ldb.JanitorInterval <= 0 || ldb.db == nil || ldb.readOnly() {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	ldb.janitorStop, ldb.janitorDone = stop, done
+
+	go func(db *leveldb.DB, interval time.Duration) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				for {
+					n, err := ldb.purgeExpired(db, time.Now(), JanitorBatchSize)
+					if err != nil || n < JanitorBatchSize {
+						break
+					}
+					select {
+					case <-stop:
+						return
+					default:
+					}
+				}
+			}
+		}
+	}(ldb.db, ldb.JanitorInterval)
+}
+
+func (ldb *LevelDB) stopJanitor() {
+	ldb.janitorMu.Lock()
+	defer ldb.janitorMu.Unlock()
+	if ldb.janitorStop == nil {
+		return
+	}
+	close(ldb.janitorStop)
+	<-ldb.janitorDone
+	ldb.janitorStop, ldb.janitorDone = nil, nil
+}
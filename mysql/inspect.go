@@ -0,0 +1,161 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/qmaru/qdb/internal"
+	"github.com/qmaru/qdb/rdb"
+)
+
+// Inspect read schema of the current database from information_schema
+func (m *MySQL) Inspect() (*rdb.Schema, error) {
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+
+	builder := internal.NewSchemaBuilder()
+	steps := []func(*internal.SchemaBuilder) error{
+		m.inspectTables,
+		m.inspectColumns,
+		m.inspectIndexes,
+		m.inspectForeignKeys,
+	}
+	for _, step := range steps {
+		if err := step(builder); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Schema(), nil
+}
+
+func (m *MySQL) inspectTables(builder *internal.SchemaBuilder) error {
+	rows, err := m.Query(`SELECT TABLE_NAME, TABLE_COMMENT FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			return err
+		}
+		builder.AddTable(name, comment)
+	}
+	return rows.Err()
+}
+
+func (m *MySQL) inspectColumns(builder *internal.SchemaBuilder) error {
+	rows, err := m.Query(`SELECT c.TABLE_NAME, c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE, c.COLUMN_DEFAULT, c.COLUMN_COMMENT, c.COLUMN_KEY, c.EXTRA
+		FROM information_schema.COLUMNS c
+		JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+		WHERE c.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE'
+		ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table    string
+			name     string
+			ctype    string
+			nullable string
+			dflt     sql.NullString
+			comment  string
+			key      string
+			extra    string
+		)
+		if err := rows.Scan(&table, &name, &ctype, &nullable, &dflt, &comment, &key, &extra); err != nil {
+			return err
+		}
+		builder.AddColumn(table, rdb.Column{
+			Name:          name,
+			Type:          ctype,
+			Nullable:      nullable == "YES",
+			Default:       internal.NullStringPtr(dflt),
+			Comment:       comment,
+			PrimaryKey:    key == "PRI",
+			AutoIncrement: strings.Contains(strings.ToLower(extra), "auto_increment"),
+		})
+	}
+	return rows.Err()
+}
+
+func (m *MySQL) inspectIndexes(builder *internal.SchemaBuilder) error {
+	rows, err := m.Query(`SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME, INDEX_TYPE
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE()
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table     string
+			name      string
+			nonUnique int
+			column    sql.NullString
+			method    string
+		)
+		if err := rows.Scan(&table, &name, &nonUnique, &column, &method); err != nil {
+			return err
+		}
+
+		var columns []string
+		if column.Valid {
+			columns = append(columns, column.String)
+		}
+		builder.AddIndex(table, rdb.Index{
+			Name:    name,
+			Columns: columns,
+			Unique:  nonUnique == 0,
+			Primary: name == "PRIMARY",
+			Method:  method,
+		})
+	}
+	return rows.Err()
+}
+
+func (m *MySQL) inspectForeignKeys(builder *internal.SchemaBuilder) error {
+	rows, err := m.Query(`SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.TABLE_SCHEMA = DATABASE() AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table     string
+			name      string
+			column    string
+			refTable  string
+			refColumn string
+			onUpdate  string
+			onDelete  string
+		)
+		if err := rows.Scan(&table, &name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		builder.AddForeignKey(table, rdb.ForeignKey{
+			Name:       name,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnUpdate:   onUpdate,
+			OnDelete:   onDelete,
+		})
+	}
+	return rows.Err()
+}
@@ -1,7 +1,6 @@
 package mysql
 
 import (
-	"bytes"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -200,30 +199,52 @@ func (m *MySQL) CreateTable(tables []any) error {
 		return err
 	}
 
-	for _, table := range tables {
-		var fieldBuf bytes.Buffer
-		var indexBuf bytes.Buffer
+	tables, err := rdb.SortTables(tables)
+	if err != nil {
+		return err
+	}
 
+	for _, table := range tables {
 		rType := reflect.TypeOf(table)
+		if rType.Kind() == reflect.Ptr {
+			rType = rType.Elem()
+		}
 		rName := rdb.DBName(rType.Name())
 
-		rdb.DBFiled(rType, &fieldBuf)
-		rdb.DBIndex(rType, &indexBuf)
+		columns := make([]string, 0)
+		var indexes []string
+		for _, field := range rdb.DBFields(rType) {
+			if field.Index != "" {
+				indexes = append(indexes, IndexDefinition(rName, field))
+			}
+			column := fmt.Sprintf("%s %s", field.Name, field.Define)
+			if field.Comment != "" {
+				column += " COMMENT " + QuoteLiteral(field.Comment)
+			}
+			columns = append(columns, column)
+		}
+
+		refs, err := rdb.DBForeignKeys(rType)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			columns = append(columns, ref.String())
+		}
+
+		fields := strings.Join(append(columns, indexes...), ",")
 
-		fields := strings.TrimRight(fieldBuf.String(), ",")
-		indexes := strings.TrimRight(indexBuf.String(), ",")
+		var tableOptions string
+		if tableComment := rdb.DBTableComment(table); tableComment != "" {
+			tableOptions = " COMMENT=" + QuoteLiteral(tableComment)
+		}
 
 		sql := fmt.Sprintf(
-			"CREATE TABLE IF NOT EXISTS `%s` (%s%s) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;",
-			rName, fields, func() string {
-				if indexes != "" {
-					return "," + indexes
-				}
-				return ""
-			}(),
+			"CREATE TABLE IF NOT EXISTS `%s` (%s) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4%s;",
+			rName, fields, tableOptions,
 		)
 
-		_, err := m.Exec(sql)
+		_, err = m.Exec(sql)
 		if err != nil {
 			return err
 		}
@@ -245,3 +266,45 @@ func (m *MySQL) Ping() error {
 
 	return nil
 }
+
+// IndexDefinition format the index tag of a field as a CREATE TABLE index definition
+//
+//	index type: btree, hash, other types use the default of the engine
+//	eg: index:"btree|unique" -> UNIQUE KEY `user_email_idx` (`email`) USING BTREE
+func IndexDefinition(table string, field rdb.Field) string {
+	method, unique, _ := strings.Cut(field.Index, "|")
+
+	key := "KEY"
+	if unique == "unique" {
+		key = "UNIQUE KEY"
+	}
+	definition := fmt.Sprintf("%s `%s_%s_idx` (`%s`)", key, table, field.Name, field.Name)
+
+	switch method = strings.ToUpper(method); method {
+	case "BTREE", "HASH":
+		definition += " USING " + method
+	}
+	return definition
+}
+
+// QuoteLiteral quote a string literal for use in sql text
+//
+//	quotes are doubled and backslashes escaped, injection-safe in every sql_mode
+//	with NO_BACKSLASH_ESCAPES a backslash is stored twice: a\b -> a\\b
+//	eg: it's -> 'it''s'
+func QuoteLiteral(literal string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range literal {
+		switch r {
+		case '\'':
+			b.WriteString(`''`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package postgresql
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/qmaru/qdb/internal"
+	"github.com/qmaru/qdb/rdb"
+)
+
+// Inspect read schema of the current schema from pg_catalog
+func (p *PostgreSQL) Inspect() (*rdb.Schema, error) {
+	if err := p.Connect(); err != nil {
+		return nil, err
+	}
+
+	builder := internal.NewSchemaBuilder()
+	steps := []func(*internal.SchemaBuilder) error{
+		p.inspectTables,
+		p.inspectColumns,
+		p.inspectIndexes,
+		p.inspectForeignKeys,
+	}
+	for _, step := range steps {
+		if err := step(builder); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Schema(), nil
+}
+
+// pgAction convert pg_constraint action code
+func pgAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return "NO ACTION"
+	}
+}
+
+func (p *PostgreSQL) inspectTables(builder *internal.SchemaBuilder) error {
+	rows, err := p.Query(`SELECT c.relname, COALESCE(obj_description(c.oid, 'pg_class'), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			return err
+		}
+		builder.AddTable(name, comment)
+	}
+	return rows.Err()
+}
+
+func (p *PostgreSQL) inspectColumns(builder *internal.SchemaBuilder) error {
+	rows, err := p.Query(`SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			pg_get_expr(d.adbin, d.adrelid), COALESCE(col_description(c.oid, a.attnum), ''), a.attidentity::text
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table    string
+			name     string
+			ctype    string
+			nullable bool
+			dflt     sql.NullString
+			comment  string
+			identity string
+		)
+		if err := rows.Scan(&table, &name, &ctype, &nullable, &dflt, &comment, &identity); err != nil {
+			return err
+		}
+		// serial columns default to nextval, identity columns have attidentity 'a' or 'd'
+		builder.AddColumn(table, rdb.Column{
+			Name:          name,
+			Type:          ctype,
+			Nullable:      nullable,
+			Default:       internal.NullStringPtr(dflt),
+			Comment:       comment,
+			AutoIncrement: strings.HasPrefix(dflt.String, "nextval(") || identity != "",
+		})
+	}
+	return rows.Err()
+}
+
+func (p *PostgreSQL) inspectIndexes(builder *internal.SchemaBuilder) error {
+	rows, err := p.Query(`SELECT t.relname, i.relname, ix.indisunique, ix.indisprimary, am.amname, a.attname
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_am am ON am.oid = i.relam
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+		LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = current_schema() AND t.relkind IN ('r', 'p')
+		ORDER BY t.relname, i.relname, k.ord`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table   string
+			name    string
+			unique  bool
+			primary bool
+			method  string
+			column  sql.NullString
+		)
+		if err := rows.Scan(&table, &name, &unique, &primary, &method, &column); err != nil {
+			return err
+		}
+
+		var columns []string
+		if column.Valid {
+			columns = append(columns, column.String)
+		}
+		builder.AddIndex(table, rdb.Index{
+			Name:    name,
+			Columns: columns,
+			Unique:  unique,
+			Primary: primary,
+			Method:  method,
+		})
+	}
+	return rows.Err()
+}
+
+func (p *PostgreSQL) inspectForeignKeys(builder *internal.SchemaBuilder) error {
+	rows, err := p.Query(`SELECT t.relname, con.conname, a.attname, rt.relname, ra.attname, con.confupdtype, con.confdeltype
+		FROM pg_constraint con
+		JOIN pg_class t ON t.oid = con.conrelid
+		JOIN pg_class rt ON rt.oid = con.confrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+		WHERE con.contype = 'f' AND n.nspname = current_schema()
+		ORDER BY t.relname, con.conname, k.ord`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			table     string
+			name      string
+			column    string
+			refTable  string
+			refColumn string
+			onUpdate  string
+			onDelete  string
+		)
+		if err := rows.Scan(&table, &name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		builder.AddForeignKey(table, rdb.ForeignKey{
+			Name:       name,
+			Columns:    []string{column},
+			RefTable:   refTable,
+			RefColumns: []string{refColumn},
+			OnUpdate:   pgAction(onUpdate),
+			OnDelete:   pgAction(onDelete),
+		})
+	}
+	return rows.Err()
+}
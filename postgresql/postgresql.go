@@ -10,7 +10,7 @@ import (
 
 	"github.com/qmaru/qdb/rdb"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Tx = *sql.Tx
@@ -202,14 +202,29 @@ func (p *PostgreSQL) CreateTable(tables []any) error {
 		return err
 	}
 
+	tables, err := rdb.SortTables(tables)
+	if err != nil {
+		return err
+	}
+
 	for _, table := range tables {
 		var buffer bytes.Buffer
 		rType := reflect.TypeOf(table)
 		rName := rdb.DBName(rType.Name())
 		rdb.DBFiled(rType, &buffer)
+
+		refs, err := rdb.DBForeignKeys(rType)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			buffer.WriteString(ref.String())
+			buffer.WriteString(",")
+		}
+
 		rFiled := buffer.Bytes()[0 : len(buffer.Bytes())-1]
 		sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", rName, rFiled)
-		_, err := p.Exec(sql)
+		_, err = p.Exec(sql)
 		if err != nil {
 			return err
 		}
@@ -225,16 +240,24 @@ func (p *PostgreSQL) Comment(tables []any) error {
 	}
 
 	for _, table := range tables {
-		var buffer bytes.Buffer
 		rType := reflect.TypeOf(table)
+		if rType.Kind() == reflect.Ptr {
+			rType = rType.Elem()
+		}
 		rName := rdb.DBName(rType.Name())
-		rdb.DBComment(rType, &buffer)
-		rFiled := buffer.Bytes()[0 : len(buffer.Bytes())-1]
 
-		commentList := strings.Split(string(rFiled), ",")
-		for _, comment := range commentList {
-			rComment := strings.Split(comment, ":")
-			sql := fmt.Sprintf(`COMMENT ON COLUMN %s.%s IS '%s';`, rName, rComment[0], rComment[1])
+		if tableComment := rdb.DBTableComment(table); tableComment != "" {
+			sql := fmt.Sprintf(`COMMENT ON TABLE %s IS %s;`, rName, pq.QuoteLiteral(tableComment))
+			if _, err := p.Exec(sql); err != nil {
+				return err
+			}
+		}
+
+		for _, field := range rdb.DBFields(rType) {
+			if field.Comment == "" {
+				continue
+			}
+			sql := fmt.Sprintf(`COMMENT ON COLUMN %s.%s IS %s;`, rName, field.Name, pq.QuoteLiteral(field.Comment))
 			_, err := p.Exec(sql)
 			if err != nil {
 				return err
@@ -267,7 +290,7 @@ func (p *PostgreSQL) CreateIndex(tables []any) error {
 			rTypeCheck := strings.Split(rType, "|")
 			var indexTag string
 			var uniqueTag string
-			if len(rTypeCheck) == 2 {
+			if len(rTypeCheck) >= 2 {
 				uniqueTag = rTypeCheck[1]
 			}
 			indexTag = rTypeCheck[0]
@@ -278,6 +301,14 @@ func (p *PostgreSQL) CreateIndex(tables []any) error {
 			if indexTag == "hnsw" {
 				// uniqueTag: vector_l2_ops | vector_ip_ops | vector_cosine_ops | vector_l1_ops | bit_hamming_ops | bit_jaccard_ops
 				indexSql = fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING %s (%s %s);`, rIndexName, rName, indexTag, rFiled, uniqueTag)
+			} else if indexTag == "ivfflat" {
+				// uniqueTag: vector_l2_ops | vector_ip_ops | vector_cosine_ops
+				// optional lists: index:"ivfflat|vector_cosine_ops|100"
+				var withTag string
+				if len(rTypeCheck) == 3 {
+					withTag = fmt.Sprintf(" WITH (lists = %s)", rTypeCheck[2])
+				}
+				indexSql = fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s USING %s (%s %s)%s;`, rIndexName, rName, indexTag, rFiled, uniqueTag, withTag)
 			} else {
 				indexSql = fmt.Sprintf(`CREATE %s INDEX IF NOT EXISTS %s ON %s USING %s (%s);`, uniqueTag, rIndexName, rName, indexTag, rFiled)
 			}
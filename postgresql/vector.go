@@ -0,0 +1,142 @@
+package postgresql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// VectorMetric pgvector distance operator
+type VectorMetric string
+
+const (
+	VectorL2           VectorMetric = "<->"
+	VectorInnerProduct VectorMetric = "<#>"
+	VectorCosine       VectorMetric = "<=>"
+	VectorL1           VectorMetric = "<+>"
+)
+
+// Vector pgvector column value
+//
+//	eg: Embedding postgresql.Vector `json:"embedding" db:"vector(3)"`
+type Vector []float32
+
+// NewVector create a vector from float32 slice
+func NewVector(values []float32) Vector {
+	return Vector(values)
+}
+
+// Slice return the underlying float32 slice
+func (v Vector) Slice() []float32 {
+	return []float32(v)
+}
+
+// String format vector as pgvector text: [1,2,3]
+func (v Vector) String() string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// Value implements driver.Valuer
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return v.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (v *Vector) Scan(src any) error {
+	var s string
+	switch data := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		s = string(data)
+	case string:
+		s = data
+	default:
+		return fmt.Errorf("cannot scan %T into Vector", src)
+	}
+
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return fmt.Errorf("invalid vector: %q", s)
+	}
+
+	s = s[1 : len(s)-1]
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	vec := make(Vector, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector element %q: %v", part, err)
+		}
+		vec[i] = float32(f)
+	}
+	*v = vec
+	return nil
+}
+
+func (m VectorMetric) valid() bool {
+	switch m {
+	case VectorL2, VectorInnerProduct, VectorCosine, VectorL1:
+		return true
+	}
+	return false
+}
+
+// quoteTable quotes a table name, schema.table is quoted per part
+func quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// CreateVectorExtension enable pgvector extension
+func (p *PostgreSQL) CreateVectorExtension() error {
+	_, err := p.Exec("CREATE EXTENSION IF NOT EXISTS vector")
+	return err
+}
+
+// NearestNeighborsWithTx Query the k nearest rows by vector distance with Tx
+//
+//	rows contain all columns of the table followed by a distance column
+func (p *PostgreSQL) NearestNeighborsWithTx(tx Tx, table, column string, vec Vector, k int, metric VectorMetric) (*sql.Rows, error) {
+	if !metric.valid() {
+		return nil, fmt.Errorf("unknown vector metric: %q", metric)
+	}
+
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be greater than 0")
+	}
+
+	column = pq.QuoteIdentifier(column)
+	query := fmt.Sprintf(`SELECT *, %s %s $1 AS distance FROM %s ORDER BY %s %s $1 LIMIT $2`, column, metric, quoteTable(table), column, metric)
+	return p.QueryWithTx(tx, query, vec, k)
+}
+
+// NearestNeighbors Query the k nearest rows by vector distance
+func (p *PostgreSQL) NearestNeighbors(table, column string, vec Vector, k int, metric VectorMetric) (*sql.Rows, error) {
+	return p.NearestNeighborsWithTx(nil, table, column, vec, k, metric)
+}
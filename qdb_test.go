@@ -1,8 +1,18 @@
 package qdb
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,7 +22,9 @@ import (
 	"github.com/qmaru/qdb/cache/lrubloom"
 	"github.com/qmaru/qdb/cache/redis"
 	"github.com/qmaru/qdb/leveldb"
+	"github.com/qmaru/qdb/mysql"
 	"github.com/qmaru/qdb/postgresql"
+	"github.com/qmaru/qdb/rdb"
 	"github.com/qmaru/qdb/sqlite"
 	"github.com/qmaru/qdb/sqlitep"
 )
@@ -57,6 +69,396 @@ func TestBadgerDB(t *testing.T) {
 	})
 }
 
+func TestBadgerGC(t *testing.T) {
+	db := badger.New(filepath.Join(t.TempDir(), "gc"), nil)
+	results := make(chan badger.GCResult, 1)
+	db.SetGC(20*time.Millisecond, 0.5)
+	db.SetGCCallback(func(result badger.GCResult) {
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	err := db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("gc"), []byte("value"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected background gc run")
+	}
+
+	if _, err := db.RunGC(); err != nil {
+		t.Fatal(err)
+	}
+
+	mem := badger.New("", nil)
+	mem.SetMemoryMode(true)
+	defer mem.Close()
+	if n, err := mem.RunGC(); err != nil || n != 0 {
+		t.Fatalf("expected gc skipped in memory mode: %d %v", n, err)
+	}
+}
+
+func TestBadgerBackup(t *testing.T) {
+	src := badger.New("", nil)
+	src.SetMemoryMode(true)
+	defer src.Close()
+
+	err := src.Update(func(txn *badger.Txn) error {
+		for _, key := range []string{"user:1", "user:2", "order:1"} {
+			if err := txn.Set([]byte(key), []byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	since, err := src.Backup(&buffer, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if since == 0 {
+		t.Fatal("expected backup version")
+	}
+
+	dst := badger.New("", nil)
+	dst.SetMemoryMode(true)
+	defer dst.Close()
+	if err := dst.Load(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	err = dst.Stream(context.Background(), []byte("user:"), func(key, value []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if got := strings.Join(keys, ","); got != "user:1,user:2" {
+		t.Fatalf("expected user:1,user:2, got %s", got)
+	}
+}
+
+func TestBadgerWatch(t *testing.T) {
+	db := badger.New("", nil)
+	db.SetMemoryMode(true)
+
+	events, err := db.Watch(context.Background(), []byte("user:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the subscription is registered asynchronously, write until it is seen
+	waitWatch(t, events, func(int) error {
+		return db.Set([]byte("user:ready"), nil)
+	})
+
+	for _, fn := range []func(txn *badger.Txn) error{
+		func(txn *badger.Txn) error { return txn.Set([]byte("order:1"), []byte("1")) },
+		func(txn *badger.Txn) error { return txn.Set([]byte("user:1"), []byte("qmaru")) },
+		func(txn *badger.Txn) error { return txn.Delete([]byte("user:1")) },
+	} {
+		if err := db.Update(fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, expected := range []struct {
+		value   string
+		deleted bool
+	}{{"qmaru", false}, {"", true}} {
+		select {
+		case event := <-events:
+			for string(event.Key) == "user:ready" {
+				event = <-events
+			}
+			if string(event.Key) != "user:1" || string(event.Value) != expected.value || event.Deleted != expected.deleted {
+				t.Fatalf("unexpected event: %+v", event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected watch event")
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// late user:ready events may still be buffered
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected channel closed after Close")
+		}
+	}
+}
+
+// waitWatch calls write with an attempt number until the first event arrives
+func waitWatch(t *testing.T, events <-chan badger.Event, write func(attempt int) error) badger.Event {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for attempt := 0; ; attempt++ {
+		if err := write(attempt); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("expected watch event")
+		}
+	}
+}
+
+func TestBadgerKV(t *testing.T) {
+	db := badger.New("", nil)
+	db.SetMemoryMode(true)
+	defer db.Close()
+
+	if err := db.Set([]byte("kv:1"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetWithTTL([]byte("kv:2"), []byte("2"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetWithMeta([]byte("kv:3"), []byte("3"), 7, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := db.Get([]byte("kv:1")); err != nil || string(v) != "1" {
+		t.Fatalf("expected kv:1, got %s %v", v, err)
+	}
+	entry, err := db.GetEntry([]byte("kv:2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ExpiresAt == 0 {
+		t.Fatal("expected kv:2 expires")
+	}
+
+	if err := db.Delete([]byte("kv:1")); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Get([]byte("kv:1"))
+	var notFound *badger.KeyNotFoundError
+	if !errors.As(err, &notFound) || !badger.IsNotFound(err) || string(notFound.Key) != "kv:1" {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+	if ok, err := db.Has([]byte("kv:1")); err != nil || ok {
+		t.Fatalf("expected kv:1 absent: %v %v", ok, err)
+	}
+
+	values, err := db.GetMany([][]byte{[]byte("kv:1"), []byte("kv:2"), []byte("kv:3")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || string(values["kv:3"]) != "3" {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	var scanned []string
+	err = db.PrefixScan([]byte("kv:"), func(entry *badger.Entry) error {
+		scanned = append(scanned, fmt.Sprintf("%s=%d", entry.Key, entry.UserMeta))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(scanned, ","); got != "kv:2=0,kv:3=7" {
+		t.Fatalf("expected kv:2=0,kv:3=7, got %s", got)
+	}
+}
+
+func TestBadgerSequence(t *testing.T) {
+	db := badger.New("", nil)
+	db.SetMemoryMode(true)
+	defer db.Close()
+
+	seq, err := db.Sequence([]byte("seq:user"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		id, err := seq.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != i {
+			t.Fatalf("expected id %d, got %d", i, id)
+		}
+	}
+
+	counter, err := db.MergeOperator([]byte("merge:views"), badger.MergeInt64Add, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := db.MergeOperator([]byte("merge:list"), badger.MergeAppend, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := counter.Add(badger.Int64ToBytes(int64(i))); err != nil {
+			t.Fatal(err)
+		}
+		if err := list.Add([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, err := counter.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if badger.BytesToInt64(total) != 6 {
+		t.Fatalf("expected 6, got %d", badger.BytesToInt64(total))
+	}
+	items, err := list.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(items) != "123" {
+		t.Fatalf("expected 123, got %s", items)
+	}
+}
+
+func TestBadgerLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	db := badger.New(filepath.Join(t.TempDir(), "logger"), nil)
+	db.SetLogger(badger.NewSlogLogger(slog.New(slog.NewTextHandler(&buffer, nil))))
+	if err := db.Set([]byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "level=INFO") {
+		t.Fatalf("expected badger logs in slog, got %q", buffer.String())
+	}
+
+	silent := badger.New(filepath.Join(t.TempDir(), "silent"), nil)
+	silent.SetLogger(badger.NewSilentLogger())
+	defer silent.Close()
+	if err := silent.Set([]byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBadgerManaged(t *testing.T) {
+	db := badger.New("", nil)
+	db.SetMemoryMode(true)
+	db.SetManagedMode(true)
+	defer db.Close()
+
+	key := []byte("managed")
+	for ts, value := range []string{"v1", "v2"} {
+		commitTs := uint64(ts + 1)
+		err := db.UpdateAt(commitTs, commitTs, func(txn *badger.Txn) error {
+			return txn.Set(key, []byte(value))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Update(func(txn *badger.Txn) error { return nil }); !errors.Is(err, badger.ErrManagedTxn) {
+		t.Fatalf("expected ErrManagedTxn, got %v", err)
+	}
+
+	err := db.ViewAt(1, func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if string(value) != "v1" {
+			t.Fatalf("expected v1 at ts 1, got %s", value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.ViewAt(2, func(txn *badger.Txn) error {
+		versions, err := badger.KeyVersions(txn, key)
+		if err != nil {
+			return err
+		}
+		if len(versions) != 2 || string(versions[0].Value) != "v2" || versions[1].Version != 1 {
+			t.Fatalf("expected 2 versions newest first, got %d", len(versions))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := db.Backup(&buffer, 0); !errors.Is(err, badger.ErrManagedTxn) {
+		t.Fatalf("expected ErrManagedTxn from Backup, got %v", err)
+	}
+	noop := func(key, value []byte) error { return nil }
+	if err := db.Stream(context.Background(), nil, noop); !errors.Is(err, badger.ErrManagedTxn) {
+		t.Fatalf("expected ErrManagedTxn from Stream, got %v", err)
+	}
+	var streamed []string
+	err = db.StreamAt(context.Background(), 1, []byte("managed"), func(key, value []byte) error {
+		streamed = append(streamed, string(value))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(streamed, ",") != "v1" {
+		t.Fatalf("expected v1 at ts 1, got %v", streamed)
+	}
+
+	events, err := db.Watch(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := waitWatch(t, events, func(attempt int) error {
+		ts := uint64(attempt + 3)
+		return db.UpdateAt(ts, ts, func(txn *badger.Txn) error {
+			return txn.Set(key, []byte(strconv.FormatUint(ts, 10)))
+		})
+	})
+	if event.Version < 3 || string(event.Value) != strconv.FormatUint(event.Version, 10) {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	unmanaged := badger.New("", nil)
+	unmanaged.SetMemoryMode(true)
+	defer unmanaged.Close()
+	if err := unmanaged.ViewAt(1, func(txn *badger.Txn) error { return nil }); err == nil {
+		t.Fatal("expected error when not in managed mode")
+	}
+}
+
 func TestBoltDB(t *testing.T) {
 	bucketName := "qmaru"
 	key := "qmaru"
@@ -134,6 +536,739 @@ func TestBoltDB(t *testing.T) {
 	})
 }
 
+type boltUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func TestBoltDBTypedBucket(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "typed.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	users := boltdb.NewTypedBucket[int64, boltUser](db.Bucket("users"), boltdb.Int64Key{}, boltdb.JSONCodec{})
+	if err := users.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int64{3, -1, 2} {
+		if err := users.Put(id, boltUser{Name: fmt.Sprintf("user%d", id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	user, ok, err := users.Get(2)
+	if err != nil || !ok || user.Name != "user2" {
+		t.Fatalf("unexpected get: %+v %v %v", user, ok, err)
+	}
+
+	if _, ok, err := users.Get(100); err != nil || ok {
+		t.Fatalf("expected missing key: %v %v", ok, err)
+	}
+
+	var ids []int64
+	if err := users.ForEach(func(id int64, _ boltUser) error {
+		ids = append(ids, id)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[-1 2 3]" {
+		t.Fatalf("unexpected key order: %v", ids)
+	}
+
+	counters := boltdb.NewTypedBucket[time.Time, int](db.Bucket("counters"), boltdb.TimeKey{}, boltdb.BinaryCodec{})
+	if err := counters.Create(); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	if err := counters.Put(now, 42); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok, err := counters.Get(now); err != nil || !ok || n != 42 {
+		t.Fatalf("unexpected counter: %v %v %v", n, ok, err)
+	}
+}
+
+type binaryRecord struct {
+	Name    string            `binary:"name"`
+	Tags    []string          `binary:"tags"`
+	Scores  map[string]int    `binary:"scores"`
+	Parent  *binaryRecord     `binary:"parent"`
+	Created time.Time         `binary:"created"`
+	Raw     []byte            `binary:"raw"`
+	Ratio   float64           `binary:"ratio"`
+	Extra   map[string]string `binary:"-"`
+}
+
+func TestBoltDBBinaryCodec(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "binary.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	records := boltdb.NewTypedBucket[string, binaryRecord](db.Bucket("records"), boltdb.StringKey{}, boltdb.BinaryCodec{})
+	if err := records.Create(); err != nil {
+		t.Fatal(err)
+	}
+	record := binaryRecord{
+		Name:    "qmaru",
+		Tags:    []string{"a", "b"},
+		Scores:  map[string]int{"x": -300, "y": 70000},
+		Parent:  &binaryRecord{Name: "root"},
+		Created: time.Unix(1700000000, 5).UTC(),
+		Raw:     []byte{0, 1, 2},
+		Ratio:   0.5,
+		Extra:   map[string]string{"skip": "me"},
+	}
+	if err := records.Put("r1", record); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := records.Get("r1")
+	if err != nil || !ok {
+		t.Fatalf("unexpected get: %v %v", ok, err)
+	}
+	record.Extra = nil
+	if !reflect.DeepEqual(got, record) {
+		t.Fatalf("unexpected record: %+v", got)
+	}
+
+	codec := boltdb.BinaryCodec{}
+	data, err := codec.Marshal(map[string]any{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{0x81, 0xa1, 'a', 0x01}) {
+		t.Fatalf("unexpected encoding: %x", data)
+	}
+	var generic any
+	if err := codec.Unmarshal(data, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := generic.(map[string]any); !ok || m["a"] != int64(1) {
+		t.Fatalf("unexpected generic value: %#v", generic)
+	}
+
+	var small int8
+	data, _ = codec.Marshal(1000)
+	if err := codec.Unmarshal(data, &small); err == nil {
+		t.Fatal("expected overflow error")
+	}
+	if _, err := codec.Marshal(make(chan int)); err == nil {
+		t.Fatal("expected unsupported type error")
+	}
+
+	// url.URL implements MarshalBinary with a pointer receiver
+	type link struct {
+		U url.URL
+	}
+	in := link{U: url.URL{Scheme: "https", Host: "example.com", Path: "/a"}}
+	data, err = codec.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out link
+	if err := codec.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.U.String() != in.U.String() {
+		t.Fatalf("unexpected url: %s", out.U.String())
+	}
+}
+
+func TestBoltDBTimeKey(t *testing.T) {
+	times := []time.Time{
+		{},
+		time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Unix(-1, 999999999),
+		time.Unix(0, 0),
+		time.Unix(1700000000, 5),
+		time.Date(3000, 1, 1, 0, 0, 0, 1, time.UTC),
+	}
+
+	var prev []byte
+	for _, tm := range times {
+		key, err := boltdb.TimeKey{}.EncodeKey(tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			t.Fatalf("expected %v to sort after the previous time", tm)
+		}
+		prev = key
+
+		decoded, err := boltdb.TimeKey{}.DecodeKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(tm) {
+			t.Fatalf("expected %v, got %v", tm, decoded)
+		}
+	}
+}
+
+func TestBoltDBIndex(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "index.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	users := boltdb.NewTypedBucket[int64, boltUser](db.Bucket("users"), boltdb.Int64Key{}, nil)
+	users.AddIndex("email", func(u boltUser) [][]byte {
+		return [][]byte{[]byte(u.Email)}
+	})
+	if err := users.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, email := range []string{"a@qmaru", "b@qmaru", "c@qmaru", "ab@qmaru"} {
+		if err := users.Put(int64(i), boltUser{Name: fmt.Sprintf("user%d", i), Email: email}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	find := func(email string) string {
+		found, err := users.FindBy("email", []byte(email))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, u := range found {
+			names = append(names, u.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := find("a@qmaru"); got != "user0" {
+		t.Fatalf("expected user0, got %s", got)
+	}
+
+	// update moves the index entry
+	if err := users.Put(1, boltUser{Name: "user1", Email: "a@qmaru"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := find("a@qmaru"); got != "user0,user1" {
+		t.Fatalf("expected user0,user1, got %s", got)
+	}
+	if got := find("b@qmaru"); got != "" {
+		t.Fatalf("expected no user, got %s", got)
+	}
+
+	if err := users.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := find("a@qmaru"); got != "user1" {
+		t.Fatalf("expected user1, got %s", got)
+	}
+
+	var names []string
+	err := users.FindByRange("email", boltdb.ScanOptions{Start: []byte("ab@qmaru"), End: []byte("c@qmaru"), EndInclusive: true}, func(_ int64, u boltUser) error {
+		names = append(names, u.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names, ","); got != "user3,user2" {
+		t.Fatalf("expected user3,user2, got %s", got)
+	}
+
+	if err := users.Reindex(); err != nil {
+		t.Fatal(err)
+	}
+	if got := find("c@qmaru"); got != "user2" {
+		t.Fatalf("expected user2 after reindex, got %s", got)
+	}
+
+	if _, err := users.FindBy("name", []byte("user1")); err == nil {
+		t.Fatal("expected error for unknown index")
+	}
+}
+
+func TestBoltDBIndexOrder(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "index_order.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	newUsers := func() *boltdb.TypedBucket[string, boltUser] {
+		users := boltdb.NewTypedBucket[string, boltUser](db.Bucket("users"), boltdb.StringKey{}, nil)
+		return users.AddIndex("email", func(u boltUser) [][]byte {
+			return [][]byte{[]byte(u.Email)}
+		})
+	}
+	users := newUsers()
+	if err := users.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	// key bytes follow the value, "a"+"zzz" must still sort before "ab"+"k"
+	records := map[string]string{"zzz": "a", "k": "ab", "m": "a\x00"}
+	for key, email := range records {
+		if err := users.Put(key, boltUser{Name: key, Email: email}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scan := func(opts boltdb.ScanOptions) string {
+		var emails []string
+		err := users.FindByRange("email", opts, func(_ string, u boltUser) error {
+			emails = append(emails, fmt.Sprintf("%q", u.Email))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(emails, ",")
+	}
+
+	if got := scan(boltdb.ScanOptions{Start: []byte("ab")}); got != `"ab"` {
+		t.Fatalf("expected ab, got %s", got)
+	}
+	if got := scan(boltdb.ScanOptions{Prefix: []byte("a")}); got != `"a","a\x00","ab"` {
+		t.Fatalf("expected values in order, got %s", got)
+	}
+	if got := scan(boltdb.ScanOptions{Start: []byte("a"), StartExclusive: true, End: []byte("ab")}); got != `"a\x00"` {
+		t.Fatalf("expected a\\x00, got %s", got)
+	}
+	if found, err := users.FindBy("email", []byte("a")); err != nil || len(found) != 1 || found[0].Name != "zzz" {
+		t.Fatalf("unexpected FindBy: %v %v", found, err)
+	}
+
+	// writes through the untyped bucket leave stale entries, which are skipped
+	if err := db.Bucket("users").Put([]byte("k"), []byte(`{"name":"k","email":"other"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := users.FindBy("email", []byte("ab")); len(found) != 0 {
+		t.Fatalf("expected stale entry skipped, got %v", found)
+	}
+
+	// indexes are dropped with the bucket
+	if err := db.DeleteBucket("users"); err != nil {
+		t.Fatal(err)
+	}
+	users = newUsers()
+	if err := users.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Bucket("users").Put([]byte("zzz"), []byte(`{"name":"zzz","email":"a"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := users.FindBy("email", []byte("a")); len(found) != 0 {
+		t.Fatalf("expected no index entries after drop, got %v", found)
+	}
+}
+
+func TestBoltDBNestedBucket(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "nested.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	users := db.Bucket("tenants", "acme", "users")
+	if err := users.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.PutString("qmaru", "best"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Bucket("tenants", "globex").Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := db.Bucket("tenants").Bucket("acme", "users").GetString("qmaru")
+	if err != nil || val != "best" {
+		t.Fatalf("unexpected nested value: %q %v", val, err)
+	}
+
+	children, err := db.Bucket("tenants").ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(children) != "[acme globex]" {
+		t.Fatalf("unexpected children: %v", children)
+	}
+
+	paths, err := db.ListBucketsRecursive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("unexpected bucket paths: %v", paths)
+	}
+
+	kvs, err := db.Bucket("tenants", "acme").ListKeyValues()
+	if err != nil || len(kvs) != 0 {
+		t.Fatalf("nested buckets should be skipped: %v %v", kvs, err)
+	}
+
+	if err := db.DeleteBucket("tenants", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := users.Exists(); err != nil || ok {
+		t.Fatalf("expected bucket deleted: %v %v", ok, err)
+	}
+}
+
+func TestBoltDBScan(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "scan.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	bucket := db.Bucket("scan")
+	if err := bucket.Create(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := bucket.PutString(fmt.Sprintf("k%02d", i), strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bucket.PutString("x", "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	scan := func(opts boltdb.ScanOptions) string {
+		var keys []string
+		if err := bucket.Scan(opts, func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	cases := []struct {
+		opts     boltdb.ScanOptions
+		expected string
+	}{
+		{boltdb.ScanOptions{Start: []byte("k02"), End: []byte("k05")}, "k02,k03,k04"},
+		{boltdb.ScanOptions{Start: []byte("k02"), End: []byte("k05"), StartExclusive: true, EndInclusive: true}, "k03,k04,k05"},
+		{boltdb.ScanOptions{Prefix: []byte("k"), Reverse: true, Limit: 2}, "k09,k08"},
+		{boltdb.ScanOptions{End: []byte("k02"), EndInclusive: true, Reverse: true}, "k02,k01,k00"},
+		{boltdb.ScanOptions{Start: []byte("k08")}, "k08,k09,x"},
+	}
+	for _, c := range cases {
+		if got := scan(c.opts); got != c.expected {
+			t.Fatalf("scan %+v: expected %s, got %s", c.opts, c.expected, got)
+		}
+	}
+
+	var pages []string
+	opts := boltdb.ScanOptions{Prefix: []byte("k"), Limit: 4}
+	for {
+		page, err := bucket.Page(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, item := range page.Items {
+			keys = append(keys, string(item.Key))
+		}
+		pages = append(pages, strings.Join(keys, ","))
+		if page.Next == "" {
+			break
+		}
+		opts.Cursor = page.Next
+	}
+	if fmt.Sprint(pages) != "[k00,k01,k02,k03 k04,k05,k06,k07 k08,k09]" {
+		t.Fatalf("unexpected pages: %v", pages)
+	}
+}
+
+func TestBoltDBTTL(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "ttl.db")).SetJanitorInterval(0)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	bucket := db.Bucket("sessions", "web")
+	if err := bucket.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.PutWithTTL([]byte("short"), []byte("1"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.PutWithTTL([]byte("long"), []byte("2"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.PutWithTTL([]byte("persist"), []byte("3"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// Put clears the TTL
+	if err := bucket.PutString("persist", "3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := bucket.GetString("short"); v != "1" {
+		t.Fatalf("expected short before expiry, got %q", v)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if v, _ := bucket.GetString("short"); v != "" {
+		t.Fatalf("expected short expired, got %q", v)
+	}
+	kv, err := bucket.ListKeyValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kv) != 2 || kv["long"] != "2" || kv["persist"] != "3" {
+		t.Fatalf("unexpected key values: %v", kv)
+	}
+	if _, ok, _ := bucket.TTL([]byte("long")); !ok {
+		t.Fatal("expected long to have ttl")
+	}
+
+	n, err := db.PurgeExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 purged key, got %d", n)
+	}
+
+	buckets, err := db.ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(buckets, []string{"sessions"}) {
+		t.Fatalf("expected internal buckets hidden, got %v", buckets)
+	}
+
+	// TTL records are dropped with the parent bucket
+	if err := db.DeleteBucket("sessions"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bucket.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := bucket.TTL([]byte("long")); ok {
+		t.Fatal("expected no ttl after the bucket was dropped")
+	}
+
+	if err := db.Bucket("__qdb_ttl").Drop(); err == nil {
+		t.Fatal("expected internal bucket to be reserved")
+	}
+}
+
+func TestBoltDBTTLJanitor(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "janitor.db")).SetJanitorInterval(20 * time.Millisecond)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	bucket := db.Bucket("sessions")
+	if err := bucket.Create(); err != nil {
+		t.Fatal(err)
+	}
+	// the janitor starts on commit, also for TTL writes inside Update
+	err := bucket.Update(func(tx *boltdb.TxBucket) error {
+		return tx.PutWithTTL([]byte("short"), []byte("1"), 10*time.Millisecond)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	n, err := db.PurgeExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("expected the janitor to purge expired keys, %d left", n)
+	}
+}
+
+func TestBoltDBSequence(t *testing.T) {
+	db := boltdb.New(filepath.Join(t.TempDir(), "seq.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	logs := db.Bucket("logs")
+	if err := logs.Create(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		id, err := logs.Append([]byte("log" + strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != uint64(i) {
+			t.Fatalf("expected id %d, got %d", i, id)
+		}
+	}
+
+	id, err := logs.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Fatalf("expected next id 4, got %d", id)
+	}
+
+	v, err := logs.Get(boltdb.Itob(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v) != "log2" {
+		t.Fatalf("expected log2, got %s", v)
+	}
+}
+
+func TestBoltDBBackup(t *testing.T) {
+	dir := t.TempDir()
+	db := boltdb.New(filepath.Join(dir, "src.db"))
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	users := db.Bucket("tenants", "acme", "users")
+	if err := users.Create(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := users.PutString(strconv.Itoa(i), "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Buckets["tenants/acme/users"].KeyN != 3 || stats.Buckets["tenants/acme"].BucketN != 1 {
+		t.Fatalf("unexpected bucket stats: %+v", stats.Buckets)
+	}
+
+	check := func(path string) {
+		copied := boltdb.New(path)
+		defer copied.Close()
+		kv, err := copied.Bucket("tenants", "acme", "users").ListKeyValues()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(kv) != 3 {
+			t.Fatalf("expected 3 keys in %s, got %v", path, kv)
+		}
+	}
+
+	if err := db.BackupToFile(filepath.Join(dir, "backup.db")); err != nil {
+		t.Fatal(err)
+	}
+	check(filepath.Join(dir, "backup.db"))
+
+	if err := db.Compact(filepath.Join(dir, "compact.db")); err != nil {
+		t.Fatal(err)
+	}
+	check(filepath.Join(dir, "compact.db"))
+
+	if err := db.Compact(filepath.Join(dir, "compact.db")); err == nil {
+		t.Fatal("expected error when destination exists")
+	}
+}
+
+func TestBoltDBBatch(t *testing.T) {
+	opts := boltdb.NewBoltDBOptions()
+	opts.MaxBatchSize = 16
+	opts.MaxBatchDelay = 5 * time.Millisecond
+	db := boltdb.NewWithOptions(filepath.Join(t.TempDir(), "batch.db"), &opts)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	bucket := db.Bucket("events")
+	if err := bucket.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- bucket.BatchPut([]byte(fmt.Sprintf("e%02d", i)), []byte(strconv.Itoa(i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := bucket.PutMany(map[string][]byte{"m1": []byte("1"), "m2": []byte("2")}); err != nil {
+		t.Fatal(err)
+	}
+
+	kv, err := bucket.ListKeyValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kv) != 52 || kv["e07"] != "7" || kv["m2"] != "2" {
+		t.Fatalf("unexpected key values: %d", len(kv))
+	}
+}
+
+func TestBoltDBBatchDefaults(t *testing.T) {
+	// zero batch limits fall back to the defaults
+	db := boltdb.NewWithOptions(filepath.Join(t.TempDir(), "batch_defaults.db"), &boltdb.BoltDBOptions{NoSync: true})
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	raw, err := db.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.MaxBatchSize != boltdb.DefaultMaxBatchSize || raw.MaxBatchDelay != boltdb.DefaultMaxBatchDelay {
+		t.Fatalf("unexpected batch limits: %d %v", raw.MaxBatchSize, raw.MaxBatchDelay)
+	}
+}
+
+func TestBoltDBReadOnly(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ro.db")
+
+	opts := boltdb.NewBoltDBOptions()
+	opts.NoSync = true
+	opts.FreelistType = boltdb.FreelistMapType
+	opts.FileMode = 0640
+	db := boltdb.NewWithOptions(file, &opts)
+	if err := db.Bucket("config").PutString("mode", "ro"); err == nil {
+		t.Fatal("expected error when bucket not exists")
+	}
+	if err := db.Bucket("config").Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Bucket("config").PutWithTTL([]byte("mode"), []byte("ro"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	roOpts := boltdb.NewBoltDBOptions()
+	roOpts.ReadOnly = true
+	ro := boltdb.NewWithOptions(file, &roOpts)
+	t.Cleanup(func() {
+		ro.Close()
+	})
+
+	v, err := ro.Bucket("config").GetString("mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "ro" {
+		t.Fatalf("expected ro, got %s", v)
+	}
+	if err := ro.Bucket("config").PutString("mode", "rw"); err == nil {
+		t.Fatal("expected write error in read-only mode")
+	}
+}
+
 func TestBuntDB(t *testing.T) {
 	db := buntdb.NewMemory()
 
@@ -191,6 +1326,272 @@ func TestLevelDB(t *testing.T) {
 	})
 }
 
+func TestLevelDBTTL(t *testing.T) {
+	ldb := leveldb.New(filepath.Join(t.TempDir(), "ttl")).SetJanitorInterval(20 * time.Millisecond)
+	t.Cleanup(func() {
+		ldb.Close()
+	})
+
+	if err := ldb.SetWithTTL([]byte("ttl:a"), []byte("a"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := ldb.Set([]byte("ttl:b"), []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ldb.Check([]byte("ttl:a")); !ok {
+		t.Fatal("expected ttl:a before expiry")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := ldb.Get([]byte("ttl:a")); err == nil {
+		t.Fatal("expected ttl:a expired")
+	}
+	data, err := ldb.GetBatch("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data["ttl:b"] == nil {
+		t.Fatalf("unexpected batch: %v", data)
+	}
+	if _, err := ldb.PurgeExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := ldb.TTL([]byte("ttl:a")); ok {
+		t.Fatal("expected ttl:a purged")
+	}
+
+	// internal ttl records can not be read or overwritten
+	if err := ldb.SetWithTTL([]byte("ttl:c"), []byte("c"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	internal := append([]byte("\xff\xff__qdb_ttl_k:"), "ttl:c"...)
+	if _, err := ldb.Get(internal); err == nil {
+		t.Fatal("expected internal key hidden from Get")
+	}
+	if ok, _ := ldb.Check(internal); ok {
+		t.Fatal("expected internal key hidden from Check")
+	}
+	if err := ldb.Set(internal, nil); err == nil {
+		t.Fatal("expected Set of internal key to fail")
+	}
+	if err := ldb.Del(internal); err == nil {
+		t.Fatal("expected Del of internal key to fail")
+	}
+	if err := ldb.WriteBatch(func(b *leveldb.Batch) error { b.Put(internal, nil); return nil }); err == nil {
+		t.Fatal("expected batch with internal key to fail")
+	}
+	if err := ldb.Transaction(func(tr *leveldb.Transaction) error { return tr.Delete(internal) }); err == nil {
+		t.Fatal("expected transaction delete of internal key to fail")
+	}
+	if _, ok, _ := ldb.TTL([]byte("ttl:c")); !ok {
+		t.Fatal("expected ttl:c to keep its ttl")
+	}
+}
+
+func TestLevelDBTransaction(t *testing.T) {
+	opts := leveldb.NewLevelDBOptions()
+	opts.WriteBuffer = 1 << 20
+	ldb := leveldb.NewWithOptions(filepath.Join(t.TempDir(), "tx"), &opts).SetSync(true)
+	t.Cleanup(func() {
+		ldb.Close()
+	})
+
+	if err := ldb.Set([]byte("balance:a"), []byte("10")); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := ldb.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+
+	err = ldb.Transaction(func(tr *leveldb.Transaction) error {
+		if err := tr.Put([]byte("balance:a"), []byte("5")); err != nil {
+			return err
+		}
+		return tr.Put([]byte("balance:b"), []byte("5"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ldb.Transaction(func(tr *leveldb.Transaction) error {
+		if err := tr.Delete([]byte("balance:a")); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollback")
+	})
+	if err == nil {
+		t.Fatal("expected rollback error")
+	}
+
+	if v, err := ldb.Get([]byte("balance:a")); err != nil || string(v) != "5" {
+		t.Fatalf("expected balance:a 5, got %s %v", v, err)
+	}
+	if v, err := snap.Get([]byte("balance:a")); err != nil || string(v) != "10" {
+		t.Fatalf("expected snapshot balance:a 10, got %s %v", v, err)
+	}
+	if ok, _ := snap.Has([]byte("balance:b")); ok {
+		t.Fatal("expected balance:b absent in snapshot")
+	}
+}
+
+func TestLevelDBRange(t *testing.T) {
+	ldb := leveldb.New(filepath.Join(t.TempDir(), "range"))
+	t.Cleanup(func() {
+		ldb.Close()
+	})
+
+	for i := 0; i < 10; i++ {
+		if err := ldb.Set([]byte(fmt.Sprintf("k%02d", i)), []byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ldb.Set([]byte("x"), []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ldb.SetWithTTL([]byte("k99"), []byte("99"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := func(it *leveldb.Iterator) string {
+		var keys []string
+		for k := range it.All() {
+			keys = append(keys, string(k))
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	cases := []struct {
+		opts     leveldb.RangeOptions
+		expected string
+	}{
+		{leveldb.RangeOptions{Start: []byte("k02"), End: []byte("k05")}, "k02,k03,k04"},
+		{leveldb.RangeOptions{Prefix: []byte("k"), Reverse: true, Limit: 3}, "k99,k09,k08"},
+		{leveldb.RangeOptions{Start: []byte("k09")}, "k09,k99,x"},
+	}
+	for _, c := range cases {
+		if got := keys(ldb.Range(c.opts)); got != c.expected {
+			t.Fatalf("range %+v: expected %s, got %s", c.opts, c.expected, got)
+		}
+	}
+
+	n, err := ldb.DeleteRange([]byte("k00"), []byte("k05"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 deleted keys, got %d", n)
+	}
+	n, err = ldb.DeletePrefix([]byte("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 6 {
+		t.Fatalf("expected 6 deleted keys, got %d", n)
+	}
+	if got := keys(ldb.Prefix(nil)); got != "x" {
+		t.Fatalf("expected x, got %s", got)
+	}
+	if _, ok, _ := ldb.TTL([]byte("k99")); ok {
+		t.Fatal("expected ttl of k99 removed")
+	}
+}
+
+func TestLevelDBBatch(t *testing.T) {
+	ldb := leveldb.New(filepath.Join(t.TempDir(), "batch"))
+	t.Cleanup(func() {
+		ldb.Close()
+	})
+
+	if err := ldb.SetWithTTL([]byte("b:1"), []byte("old"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ldb.WriteBatch(func(b *leveldb.Batch) error {
+		b.Put([]byte("b:1"), []byte("1"))
+		b.Put([]byte("b:2"), []byte("2"))
+		b.Delete([]byte("b:3"))
+		if b.Len() != 3 {
+			return fmt.Errorf("expected 3 records, got %d", b.Len())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ldb.WriteBatch(func(b *leveldb.Batch) error {
+		b.Delete([]byte("b:2"))
+		return fmt.Errorf("discard")
+	})
+	if err == nil {
+		t.Fatal("expected discard error")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	for _, key := range []string{"b:1", "b:2"} {
+		if _, err := ldb.Get([]byte(key)); err != nil {
+			t.Fatalf("expected %s exists: %v", key, err)
+		}
+	}
+
+	b := ldb.NewBatch()
+	b.Put([]byte("b:3"), []byte("3"))
+	b.Reset()
+	if err := b.Write(true); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ldb.Check([]byte("b:3")); ok {
+		t.Fatal("expected b:3 absent after reset")
+	}
+}
+
+func TestLevelDBStats(t *testing.T) {
+	ldb := leveldb.NewMemory()
+	t.Cleanup(func() {
+		ldb.Close()
+	})
+
+	value := []byte(strings.Repeat("v", 1024))
+	err := ldb.WriteBatch(func(b *leveldb.Batch) error {
+		for i := 0; i < 1000; i++ {
+			b.Put([]byte(fmt.Sprintf("s%04d", i)), value)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ldb.CompactRange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sizes, err := ldb.SizeOf(leveldb.Range{Start: []byte("s"), Limit: []byte("t")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 1 || sizes[0] <= 0 {
+		t.Fatalf("expected positive size, got %v", sizes)
+	}
+
+	stats, err := ldb.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := 0
+	for _, level := range stats.Levels {
+		tables += level.Tables
+	}
+	if tables == 0 {
+		t.Fatalf("expected tables after compaction, got %+v", stats.Levels)
+	}
+}
+
 func TestPostgresql(t *testing.T) {
 	psql := postgresql.NewDefault("127.0.0.1", 5432, "qmaru", "123456", "qmaru")
 	err := psql.Ping()
@@ -229,6 +1630,74 @@ func TestPostgresql(t *testing.T) {
 	})
 }
 
+func TestPostgresqlVector(t *testing.T) {
+	vec := postgresql.Vector{1, 2.5, -3}
+	value, err := vec.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "[1,2.5,-3]" {
+		t.Fatalf("unexpected vector value: %v", value)
+	}
+
+	var scanned postgresql.Vector
+	if err := scanned.Scan([]byte("[1, 2.5, -3]")); err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 3 || scanned[1] != 2.5 || scanned[2] != -3 {
+		t.Fatalf("unexpected scanned vector: %v", scanned)
+	}
+}
+
+type commentModel struct {
+	ID     uint64 `json:"id" db:"serial;PRIMARY KEY" comment:"ID, primary: key"`
+	Remark string `json:"remark,omitempty" db:"varchar;DEFAULT ''" comment:"it's a remark"`
+	Ignore string `json:"-"`
+}
+
+func (commentModel) TableComment() string {
+	return "comment model"
+}
+
+func TestRdbFields(t *testing.T) {
+	fields := rdb.DBFields(reflect.TypeOf(commentModel{}))
+	if len(fields) != 2 {
+		t.Fatalf("unexpected field count: %d", len(fields))
+	}
+	if fields[0].Comment != "ID, primary: key" || fields[0].Define != "serial PRIMARY KEY" {
+		t.Fatalf("unexpected field: %+v", fields[0])
+	}
+	if fields[1].Name != "remark" || fields[1].Comment != "it's a remark" {
+		t.Fatalf("unexpected field: %+v", fields[1])
+	}
+
+	if c := rdb.DBTableComment(commentModel{}); c != "comment model" {
+		t.Fatalf("unexpected table comment: %q", c)
+	}
+	if c := rdb.DBTableComment(&commentModel{}); c != "comment model" {
+		t.Fatalf("unexpected table comment: %q", c)
+	}
+
+	var buffer bytes.Buffer
+	rdb.DBFiled(reflect.TypeOf(commentModel{}), &buffer)
+	if got := buffer.String(); got != "id serial PRIMARY KEY,remark varchar DEFAULT ''," {
+		t.Fatalf("unexpected DBFiled: %q", got)
+	}
+
+	sql3 := sqlite.New(":memory:")
+	defer sql3.Close()
+	if err := sql3.CreateTable([]any{commentModel{}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sql3.Exec("INSERT INTO comment_model (id, remark) VALUES (1, 'ok')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if q := mysql.QuoteLiteral(`it's \`); q != `'it''s \\'` {
+		t.Fatalf("unexpected quoted literal: %s", q)
+	}
+}
+
 func TestSqlite(t *testing.T) {
 	sql3 := sqlite.New(":memory:")
 	row, err := sql3.QueryOne("select sqlite_version()")
@@ -257,6 +1726,166 @@ func TestSqlite(t *testing.T) {
 	})
 }
 
+func TestSqliteInspect(t *testing.T) {
+	sql3 := sqlite.New(":memory:")
+	t.Cleanup(func() {
+		sql3.Close()
+	})
+
+	statements := []string{
+		"CREATE TABLE user (id INTEGER PRIMARY KEY, email TEXT NOT NULL, state BOOLEAN DEFAULT 1)",
+		"CREATE UNIQUE INDEX user_email_idx ON user (email)",
+		"CREATE TABLE post (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES user(id) ON DELETE CASCADE)",
+		"CREATE TABLE tag (code TEXT PRIMARY KEY)",
+		"CREATE TABLE label (code TEXT PRIMARY KEY) WITHOUT ROWID",
+	}
+	for _, stmt := range statements {
+		if _, err := sql3.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schema, err := sql3.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := schema.Table("user")
+	if user == nil || len(user.Columns) != 3 {
+		t.Fatalf("unexpected user table: %+v", user)
+	}
+	if !user.Column("id").PrimaryKey || user.Column("email").Nullable {
+		t.Fatalf("unexpected user columns: %+v", user.Columns)
+	}
+	if d := user.Column("state").Default; d == nil || *d != "1" {
+		t.Fatalf("unexpected state default: %v", d)
+	}
+	if len(user.Indexes) != 1 || !user.Indexes[0].Unique || user.Indexes[0].Columns[0] != "email" {
+		t.Fatalf("unexpected user indexes: %+v", user.Indexes)
+	}
+
+	post := schema.Table("post")
+	if post == nil || len(post.ForeignKeys) != 1 {
+		t.Fatalf("unexpected post table: %+v", post)
+	}
+	if fk := post.ForeignKeys[0]; fk.RefTable != "user" || fk.RefColumns[0] != "id" || fk.OnDelete != "CASCADE" {
+		t.Fatalf("unexpected post foreign key: %+v", fk)
+	}
+
+	// only rowid aliases and WITHOUT ROWID/STRICT primary keys reject NULL
+	if user.Column("id").Nullable {
+		t.Fatal("expected INTEGER PRIMARY KEY to be NOT NULL")
+	}
+	if !schema.Table("tag").Column("code").Nullable {
+		t.Fatal("expected TEXT PRIMARY KEY to be nullable")
+	}
+	if schema.Table("label").Column("code").Nullable {
+		t.Fatal("expected WITHOUT ROWID primary key to be NOT NULL")
+	}
+}
+
+func TestGenerateModels(t *testing.T) {
+	sql3 := sqlite.New(":memory:")
+	t.Cleanup(func() {
+		sql3.Close()
+	})
+
+	if _, err := sql3.Exec("CREATE TABLE user_info (id INTEGER PRIMARY KEY, email TEXT NOT NULL DEFAULT '', created_at TIMESTAMP)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sql3.Exec("CREATE UNIQUE INDEX user_info_email_idx ON user_info (email)"); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := sql3.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := rdb.GenerateModels(schema, rdb.GenerateOptions{Package: "model", Dialect: rdb.DialectSqlite})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"type UserInfo struct {",
+		"ID        int64     `json:\"id\" db:\"INTEGER;PRIMARY KEY\"`",
+		"Email     string    `json:\"email\" db:\"TEXT;NOT NULL;DEFAULT ''\" index:\"btree|unique\"`",
+		"CreatedAt time.Time `json:\"created_at\" db:\"TIMESTAMP\"`",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(code), e) {
+			t.Fatalf("generated code missing %q:\n%s", e, code)
+		}
+	}
+}
+
+func TestMysqlIndexDefinition(t *testing.T) {
+	type mysqlUser struct {
+		ID    int64  `json:"id" db:"bigint;PRIMARY KEY"`
+		Email string `json:"email" db:"varchar(255);NOT NULL" index:"btree|unique"`
+		Name  string `json:"name" db:"varchar(64)" index:"gin"`
+	}
+
+	fields := rdb.DBFields(reflect.TypeOf(mysqlUser{}))
+	expected := []string{
+		"UNIQUE KEY `mysql_user_email_idx` (`email`) USING BTREE",
+		"KEY `mysql_user_name_idx` (`name`)",
+	}
+	for i, field := range fields[1:] {
+		if got := mysql.IndexDefinition("mysql_user", field); got != expected[i] {
+			t.Fatalf("expected %s, got %s", expected[i], got)
+		}
+	}
+}
+
+type fkAuthor struct {
+	ID   int64  `json:"id" db:"integer;PRIMARY KEY"`
+	Name string `json:"name" db:"text;NOT NULL"`
+}
+
+type fkBook struct {
+	ID       int64 `json:"id" db:"integer;PRIMARY KEY"`
+	AuthorID int64 `json:"author_id" db:"integer" fk:"fk_author.id;cascade;update:set null"`
+}
+
+func TestSqliteForeignKey(t *testing.T) {
+	sql3 := sqlite.New(":memory:")
+	t.Cleanup(func() {
+		sql3.Close()
+	})
+
+	sorted, err := rdb.SortTables([]any{fkBook{}, fkAuthor{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sorted[0].(fkAuthor); !ok {
+		t.Fatalf("unexpected table order: %T, %T", sorted[0], sorted[1])
+	}
+
+	if err := sql3.CreateTable([]any{fkBook{}, fkAuthor{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := sql3.Inspect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book := schema.Table("fk_book")
+	if book == nil || len(book.ForeignKeys) != 1 {
+		t.Fatalf("unexpected book table: %+v", book)
+	}
+	fk := book.ForeignKeys[0]
+	if fk.RefTable != "fk_author" || fk.OnDelete != "CASCADE" || fk.OnUpdate != "SET NULL" {
+		t.Fatalf("unexpected foreign key: %+v", fk)
+	}
+
+	if _, err := rdb.ParseReference("author_id", "fk_author.id;explode"); err == nil {
+		t.Fatal("expected invalid fk action error")
+	}
+}
+
 func TestSqlitep(t *testing.T) {
 	sql3p := sqlitep.New(":memory:")
 	row, err := sql3p.QueryOne("select sqlite_version()")
@@ -0,0 +1,312 @@
+package rdb
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dialect sql dialect of a schema
+type Dialect string
+
+const (
+	DialectSqlite     Dialect = "sqlite"
+	DialectMySQL      Dialect = "mysql"
+	DialectPostgreSQL Dialect = "postgresql"
+)
+
+// GenerateOptions options of GenerateModels
+//
+//	Package: package name of the generated file, default model
+//	Tables: tables to generate, empty means all
+//	NullablePointer: use pointer types for nullable columns
+type GenerateOptions struct {
+	Package         string
+	Dialect         Dialect
+	Tables          []string
+	NullablePointer bool
+}
+
+var commonInitialisms = map[string]string{
+	"id":   "ID",
+	"ip":   "IP",
+	"url":  "URL",
+	"uri":  "URI",
+	"uuid": "UUID",
+	"api":  "API",
+	"http": "HTTP",
+	"json": "JSON",
+	"sql":  "SQL",
+}
+
+// GenerateModels Generate go model structs from schema
+//
+//	the json|db|comment|index|fk tags follow the format of DBFiled|DBComment|DBIndex|DBForeignKeys
+func GenerateModels(schema *Schema, options GenerateOptions) ([]byte, error) {
+	if options.Package == "" {
+		options.Package = "model"
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range options.Tables {
+		wanted[name] = true
+	}
+
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+	for _, table := range schema.Tables {
+		if len(wanted) > 0 && !wanted[table.Name] {
+			continue
+		}
+		generateModel(&body, table, options, imports)
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("// Code generated by qdbgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buffer, "package %s\n\n", options.Package)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		buffer.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&buffer, "\t%q\n", path)
+		}
+		buffer.WriteString(")\n\n")
+	}
+	buffer.Write(body.Bytes())
+
+	return format.Source(buffer.Bytes())
+}
+
+func generateModel(buffer *bytes.Buffer, table Table, options GenerateOptions, imports map[string]bool) {
+	structName := GoName(table.Name, false)
+
+	var primaryKeys []string
+	for _, column := range table.Columns {
+		if column.PrimaryKey {
+			primaryKeys = append(primaryKeys, column.Name)
+		}
+	}
+
+	indexes := make(map[string]Index)
+	for _, index := range table.Indexes {
+		if index.Primary || len(index.Columns) != 1 {
+			continue
+		}
+		if _, ok := indexes[index.Columns[0]]; !ok {
+			indexes[index.Columns[0]] = index
+		}
+	}
+
+	references := make(map[string]ForeignKey)
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) == 1 && len(fk.RefColumns) == 1 {
+			references[fk.Columns[0]] = fk
+		}
+	}
+
+	fmt.Fprintf(buffer, "// %s table %s\n", structName, table.Name)
+	if DBName(structName) != table.Name {
+		fmt.Fprintf(buffer, "//\n//\ttable name %q does not match DBName(%q)\n", table.Name, structName)
+	}
+	if len(primaryKeys) > 1 {
+		fmt.Fprintf(buffer, "//\n//\tprimary key: (%s)\n", strings.Join(primaryKeys, ", "))
+	}
+	fmt.Fprintf(buffer, "type %s struct {\n", structName)
+
+	used := make(map[string]bool)
+	for _, column := range table.Columns {
+		fieldName := GoName(column.Name, true)
+		for used[fieldName] {
+			fieldName += "_"
+		}
+		used[fieldName] = true
+
+		goType, importPath := goType(column, options)
+		if importPath != "" {
+			imports[importPath] = true
+		}
+
+		tags := []string{
+			"json:" + quoteTag(column.Name),
+			"db:" + quoteTag(dbTag(column, len(primaryKeys) == 1, options.Dialect)),
+		}
+		if column.Comment != "" {
+			tags = append(tags, "comment:"+quoteTag(column.Comment))
+		}
+		if index, ok := indexes[column.Name]; ok {
+			tags = append(tags, "index:"+quoteTag(indexTag(index)))
+		}
+		if fk, ok := references[column.Name]; ok {
+			tags = append(tags, "fk:"+quoteTag(fkTag(fk)))
+		}
+		fmt.Fprintf(buffer, "\t%s %s `%s`\n", fieldName, goType, strings.Join(tags, " "))
+	}
+	buffer.WriteString("}\n\n")
+
+	if table.Comment != "" {
+		fmt.Fprintf(buffer, "func (%s) TableComment() string {\n\treturn %s\n}\n\n", structName, strconv.Quote(table.Comment))
+	}
+}
+
+// GoName formating go identifier
+//
+//	eg: user_name -> UserName
+//	eg: user_id -> UserID (initialisms)
+func GoName(name string, initialisms bool) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, part := range parts {
+		if initialisms {
+			if v, ok := commonInitialisms[strings.ToLower(part)]; ok {
+				b.WriteString(v)
+				continue
+			}
+		}
+		ru := []rune(part)
+		ru[0] = unicode.ToUpper(ru[0])
+		b.WriteString(string(ru))
+	}
+
+	result := b.String()
+	if result == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(result)[0]) {
+		return "X" + result
+	}
+	return result
+}
+
+// quoteTag quote a struct tag value, backquote is not allowed in raw string
+func quoteTag(value string) string {
+	return strings.ReplaceAll(strconv.Quote(value), "`", `\x60`)
+}
+
+func dbTag(column Column, singlePrimaryKey bool, dialect Dialect) string {
+	columnType := column.Type
+	lowerType := strings.ToLower(columnType)
+
+	autoIncrement := column.AutoIncrement
+	if autoIncrement && dialect == DialectPostgreSQL {
+		switch lowerType {
+		case "bigint":
+			columnType = "bigserial"
+		case "smallint":
+			columnType = "smallserial"
+		default:
+			columnType = "serial"
+		}
+	}
+
+	profile := []string{columnType}
+	if column.PrimaryKey && singlePrimaryKey {
+		profile = append(profile, "PRIMARY KEY")
+	} else if !column.Nullable {
+		profile = append(profile, "NOT NULL")
+	}
+
+	if autoIncrement && dialect == DialectMySQL {
+		profile = append(profile, "AUTO_INCREMENT")
+	}
+
+	if column.Default != nil && !autoIncrement {
+		profile = append(profile, "DEFAULT "+defaultValue(*column.Default, lowerType, dialect))
+	}
+	return strings.Join(profile, ";")
+}
+
+// defaultValue quote mysql string defaults which information_schema reports unquoted
+func defaultValue(value, lowerType string, dialect Dialect) string {
+	if dialect != DialectMySQL || strings.HasPrefix(value, "'") {
+		return value
+	}
+
+	upper := strings.ToUpper(value)
+	if upper == "NULL" || strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasSuffix(value, ")") {
+		return value
+	}
+
+	for _, t := range []string{"char", "text", "enum", "set", "date", "time", "year", "json", "binary", "blob"} {
+		if strings.Contains(lowerType, t) {
+			return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+		}
+	}
+	return value
+}
+
+func indexTag(index Index) string {
+	method := strings.ToLower(index.Method)
+	if method == "" {
+		method = "btree"
+	}
+	if index.Unique {
+		return method + "|unique"
+	}
+	return method
+}
+
+// fkTag format the fk tag, NO ACTION is the default and omitted
+//
+//	eg: user.id;delete:cascade
+func fkTag(fk ForeignKey) string {
+	profile := []string{fk.RefTable + "." + fk.RefColumns[0]}
+	if action := strings.ToUpper(fk.OnDelete); action != "" && action != "NO ACTION" {
+		profile = append(profile, "delete:"+strings.ToLower(action))
+	}
+	if action := strings.ToUpper(fk.OnUpdate); action != "" && action != "NO ACTION" {
+		profile = append(profile, "update:"+strings.ToLower(action))
+	}
+	return strings.Join(profile, ";")
+}
+
+func goType(column Column, options GenerateOptions) (string, string) {
+	t := strings.ToLower(column.Type)
+	base, _, _ := strings.Cut(t, "(")
+	if fields := strings.Fields(base); len(fields) > 0 {
+		base = fields[0]
+	}
+
+	var goType, importPath string
+	switch base {
+	case "bool", "boolean":
+		goType = "bool"
+	case "tinyint":
+		goType = "int64"
+		if strings.HasPrefix(t, "tinyint(1)") {
+			goType = "bool"
+		}
+	case "int", "integer", "smallint", "mediumint", "bigint", "int2", "int4", "int8", "serial", "smallserial", "bigserial":
+		goType = "int64"
+	case "float", "float4", "float8", "double", "real", "numeric", "decimal":
+		goType = "float64"
+	case "timestamp", "timestamptz", "datetime", "date", "time", "timetz":
+		goType, importPath = "time.Time", "time"
+	case "bytea", "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary":
+		goType = "[]byte"
+	case "vector":
+		goType, importPath = "postgresql.Vector", "github.com/qmaru/qdb/postgresql"
+	default:
+		goType = "string"
+	}
+
+	if goType == "int64" && strings.Contains(t, "unsigned") {
+		goType = "uint64"
+	}
+
+	if options.NullablePointer && column.Nullable && !column.PrimaryKey && goType != "[]byte" && base != "vector" {
+		goType = "*" + goType
+	}
+	return goType, importPath
+}
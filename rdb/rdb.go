@@ -31,28 +31,14 @@ func DBName(s string) string {
 	return strings.ToLower(name)
 }
 
-// DBFiled Parse the json|db field of the model
+// DBFiled Parse the json|db field of the model, same column naming as DBFields
 //
 //	eg: json:"id"
 //	eg: db:"serial;PRIMARY KEY"
 //	eg: db:"integer;DEFAULT 0"
 func DBFiled(reflectType reflect.Type, buffer *bytes.Buffer) {
-	if reflectType.Kind() != reflect.Struct {
-		return
-	}
-
-	for i := 0; i < reflectType.NumField(); i++ {
-		jsonTag := reflectType.Field(i).Tag.Get("json")
-		dbTag := reflectType.Field(i).Tag.Get("db")
-
-		if jsonTag == "" && dbTag == "" {
-			DBFiled(reflectType.Field(i).Type, buffer)
-			continue
-		}
-
-		dbProfile := strings.Split(dbTag, ";")
-		dbFiled := fmt.Sprintf("%s %s", jsonTag, strings.Join(dbProfile, " "))
-		buffer.WriteString(dbFiled)
+	for _, field := range DBFields(reflectType) {
+		buffer.WriteString(fmt.Sprintf("%s %s", field.Name, field.Define))
 		buffer.WriteString(",")
 	}
 }
@@ -106,3 +92,243 @@ func DBIndex(reflectType reflect.Type, buffer *bytes.Buffer) {
 		buffer.WriteString(",")
 	}
 }
+
+// Field Parsed column metadata of the model
+type Field struct {
+	Name       string
+	Define     string
+	Comment    string
+	Index      string
+	ForeignKey string
+}
+
+// TableCommenter Model with a table-level comment
+type TableCommenter interface {
+	TableComment() string
+}
+
+// DBFields Parse the json|db|comment|index|fk fields of the model
+//
+//	eg: json:"id" db:"serial;PRIMARY KEY" comment:"ID" index:"btree"
+//	-> Field{Name: "id", Define: "serial PRIMARY KEY", Comment: "ID", Index: "btree"}
+func DBFields(reflectType reflect.Type) []Field {
+	if reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+
+	if reflectType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make([]Field, 0, reflectType.NumField())
+	for i := 0; i < reflectType.NumField(); i++ {
+		tag := reflectType.Field(i).Tag
+		jsonTag := tag.Get("json")
+		dbTag := tag.Get("db")
+
+		if jsonTag == "" && dbTag == "" {
+			fields = append(fields, DBFields(reflectType.Field(i).Type)...)
+			continue
+		}
+
+		name, _, _ := strings.Cut(jsonTag, ",")
+		if name == "-" {
+			continue
+		}
+
+		fields = append(fields, Field{
+			Name:       name,
+			Define:     strings.Join(strings.Split(dbTag, ";"), " "),
+			Comment:    tag.Get("comment"),
+			Index:      tag.Get("index"),
+			ForeignKey: tag.Get("fk"),
+		})
+	}
+	return fields
+}
+
+// DBTableComment Return the table comment if the model implements TableCommenter
+func DBTableComment(table any) string {
+	if c, ok := table.(TableCommenter); ok {
+		return c.TableComment()
+	}
+
+	rValue := reflect.ValueOf(table)
+	if rValue.IsValid() && rValue.Kind() != reflect.Ptr {
+		ptr := reflect.New(rValue.Type())
+		ptr.Elem().Set(rValue)
+		if c, ok := ptr.Interface().(TableCommenter); ok {
+			return c.TableComment()
+		}
+	}
+	return ""
+}
+
+// Reference Foreign key of a field
+type Reference struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string
+	OnUpdate  string
+}
+
+// String format table-level FOREIGN KEY constraint
+//
+//	eg: FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+func (r Reference) String() string {
+	constraint := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s)", r.Column, r.RefTable, r.RefColumn)
+	if r.OnDelete != "" {
+		constraint += " ON DELETE " + r.OnDelete
+	}
+	if r.OnUpdate != "" {
+		constraint += " ON UPDATE " + r.OnUpdate
+	}
+	return constraint
+}
+
+// fkAction normalize foreign key action
+func fkAction(action string) (string, error) {
+	switch strings.Join(strings.FieldsFunc(strings.ToLower(action), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "") {
+	case "cascade":
+		return "CASCADE", nil
+	case "restrict":
+		return "RESTRICT", nil
+	case "setnull":
+		return "SET NULL", nil
+	case "setdefault":
+		return "SET DEFAULT", nil
+	case "noaction":
+		return "NO ACTION", nil
+	}
+	return "", fmt.Errorf("unknown foreign key action: %q", action)
+}
+
+// ParseReference Parse the fk tag of a field
+//
+//	eg: fk:"user.id"
+//	eg: fk:"user.id;cascade" -> ON DELETE CASCADE
+//	eg: fk:"user.id;delete:set null;update:cascade"
+func ParseReference(column, fkTag string) (Reference, error) {
+	profile := strings.Split(fkTag, ";")
+
+	refTable, refColumn, ok := strings.Cut(strings.TrimSpace(profile[0]), ".")
+	if !ok || refTable == "" || refColumn == "" {
+		return Reference{}, fmt.Errorf("invalid fk tag of %s: %q", column, fkTag)
+	}
+
+	ref := Reference{
+		Column:    column,
+		RefTable:  refTable,
+		RefColumn: refColumn,
+	}
+
+	for _, option := range profile[1:] {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+
+		target := &ref.OnDelete
+		if name, action, ok := strings.Cut(option, ":"); ok {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "delete":
+			case "update":
+				target = &ref.OnUpdate
+			default:
+				return Reference{}, fmt.Errorf("invalid fk option of %s: %q", column, option)
+			}
+			option = action
+		}
+
+		action, err := fkAction(option)
+		if err != nil {
+			return Reference{}, err
+		}
+		*target = action
+	}
+	return ref, nil
+}
+
+// DBForeignKeys Parse the json|fk field of the model
+func DBForeignKeys(reflectType reflect.Type) ([]Reference, error) {
+	var refs []Reference
+	for _, field := range DBFields(reflectType) {
+		if field.ForeignKey == "" {
+			continue
+		}
+		ref, err := ParseReference(field.Name, field.ForeignKey)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// SortTables Order tables so that referenced tables are created first
+//
+//	references to tables outside the slice and self references are ignored
+func SortTables(tables []any) ([]any, error) {
+	names := make([]string, len(tables))
+	position := make(map[string]int, len(tables))
+	for i, table := range tables {
+		rType := reflect.TypeOf(table)
+		if rType.Kind() == reflect.Ptr {
+			rType = rType.Elem()
+		}
+		names[i] = DBName(rType.Name())
+		position[names[i]] = i
+	}
+
+	deps := make([][]int, len(tables))
+	for i, table := range tables {
+		refs, err := DBForeignKeys(reflect.TypeOf(table))
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if j, ok := position[ref.RefTable]; ok && j != i {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	sorted := make([]any, 0, len(tables))
+	done := make([]bool, len(tables))
+	for len(sorted) < len(tables) {
+		progress := false
+		for i := range tables {
+			if done[i] {
+				continue
+			}
+
+			ready := true
+			for _, j := range deps[i] {
+				if !done[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				sorted = append(sorted, tables[i])
+				done[i] = true
+				progress = true
+				break
+			}
+		}
+
+		if !progress {
+			var cycle []string
+			for i := range tables {
+				if !done[i] {
+					cycle = append(cycle, names[i])
+				}
+			}
+			return nil, fmt.Errorf("foreign key cycle between tables: %s", strings.Join(cycle, ", "))
+		}
+	}
+	return sorted, nil
+}
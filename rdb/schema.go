@@ -0,0 +1,74 @@
+package rdb
+
+// Inspector Read the schema of a live database
+type Inspector interface {
+	Inspect() (*Schema, error)
+}
+
+// Schema Tables of a database
+type Schema struct {
+	Tables []Table `json:"tables"`
+}
+
+// Table Table structure
+type Table struct {
+	Name        string       `json:"name"`
+	Comment     string       `json:"comment"`
+	Columns     []Column     `json:"columns"`
+	Indexes     []Index      `json:"indexes"`
+	ForeignKeys []ForeignKey `json:"foreign_keys"`
+}
+
+// Column Column structure
+//
+//	Default is nil when the column has no default value
+type Column struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	Nullable      bool    `json:"nullable"`
+	Default       *string `json:"default"`
+	Comment       string  `json:"comment"`
+	PrimaryKey    bool    `json:"primary_key"`
+	AutoIncrement bool    `json:"auto_increment"`
+}
+
+// Index Index structure
+//
+//	Method is empty when the database does not report it (sqlite)
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+	Method  string   `json:"method"`
+}
+
+// ForeignKey Foreign key structure
+type ForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+	OnUpdate   string   `json:"on_update"`
+	OnDelete   string   `json:"on_delete"`
+}
+
+// Table find a table by name
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Column find a column by name
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}